@@ -0,0 +1,773 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thedevscott/blogaggregator/internal/config"
+	"github.com/thedevscott/blogaggregator/internal/database"
+	"github.com/thedevscott/blogaggregator/internal/feed"
+)
+
+func HandlerAggregate(s *State, cmd Command) error {
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	workers := flags.Int("workers", 5, "number of feeds fetched concurrently")
+	batch := flags.Int("batch", 20, "number of stale feeds claimed per tick")
+	perHost := flags.Int("per-host", 2, "maximum concurrent fetches against one host")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 30*time.Second, "how long in-flight fetches may run after SIGINT/SIGTERM")
+	once := flags.Bool("once", false, "fetch every due feed once and exit")
+	usage := fmt.Errorf("usage: %v [--workers N] [--batch M] [--per-host K] [--shutdown-timeout D] <delay_between_requests> | --once", cmd.Name)
+
+	if err := flags.Parse(cmd.Args); err != nil {
+		return usage
+	}
+	if *once && flags.NArg() != 0 || !*once && flags.NArg() != 1 {
+		return usage
+	}
+	if *workers < 1 || *batch < 1 || *perHost < 1 {
+		return errors.New("--workers, --batch and --per-host must be at least 1")
+	}
+
+	var timeBetweenRequests time.Duration
+	if !*once {
+		var err error
+		timeBetweenRequests, err = time.ParseDuration(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid delay duration: %w", err)
+		}
+	}
+
+	// s.Ctx is cancelled on SIGINT/SIGTERM, which stops new feeds from being
+	// claimed. Fetches already running get their own context, cancelled only
+	// once the shutdown timeout has passed, so they can finish cleanly.
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(s.Ctx))
+	defer cancelWork()
+	stopWatch := context.AfterFunc(s.Ctx, func() {
+		log.Printf("Shutting down, waiting up to %s for in-flight fetches (Ctrl-C again to quit now)...", *shutdownTimeout)
+		time.AfterFunc(*shutdownTimeout, cancelWork)
+	})
+	defer stopWatch()
+
+	pool := newScrapePool(s.Db, s.Cfg, *workers, *perHost)
+
+	if *once {
+		return aggregateOnce(s.Ctx, workCtx, s.Db, pool, *batch)
+	}
+
+	log.Printf("Collecting up to %d feeds every %s with %d workers...", *batch, timeBetweenRequests, *workers)
+
+	ticker := time.NewTicker(timeBetweenRequests)
+	defer ticker.Stop()
+
+	total := tickStats{}
+	for {
+		stats, err := scrapeFeeds(s.Ctx, workCtx, s.Db, pool, *batch)
+		if err != nil {
+			log.Println(err)
+		}
+		total.add(stats)
+
+		select {
+		case <-s.Ctx.Done():
+			log.Printf("Aggregator stopped: %d feeds fetched (%d failed, %d interrupted), %d new posts",
+				total.feeds, total.failed, total.interrupted, total.posts)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// aggregateOnce fetches batches until no feed is due, then exits. Fetched
+// feeds are rescheduled into the future, so each due feed is fetched once.
+func aggregateOnce(ctx, workCtx context.Context, db *database.Queries, pool *scrapePool, batch int) error {
+	log.Printf("Collecting every due feed once with %d workers...", pool.workers)
+
+	total := tickStats{}
+	for ctx.Err() == nil {
+		stats, err := scrapeFeeds(ctx, workCtx, db, pool, batch)
+		if err != nil {
+			return err
+		}
+		if stats.feeds == 0 {
+			break
+		}
+		total.add(stats)
+	}
+
+	log.Printf("Aggregator done: %d feeds fetched (%d failed, %d interrupted), %d new posts",
+		total.feeds, total.failed, total.interrupted, total.posts)
+
+	if total.failed > 0 || total.interrupted > 0 {
+		return fmt.Errorf("%d of %d feed fetches did not complete", total.failed+total.interrupted, total.feeds)
+	}
+	return nil
+}
+
+// feedClaimLease is how long a claimed feed is reserved for this process. A
+// batch is claimed at once but fetched over time, so the lease is renewed when
+// each fetch starts and only needs to outlast one fetch; if the process dies
+// the claim expires and another aggregator picks the feed up.
+const feedClaimLease = 5 * time.Minute
+
+// scrapeFeeds claims the next batch of stale feeds and fetches them with the
+// worker pool, logging the tick's throughput. Claiming is atomic, so any
+// number of aggregators can share the feeds table without fetching the same
+// feed twice. Nothing new is claimed or started once ctx is done; fetches
+// run with workCtx. An error means no feeds could be claimed.
+func scrapeFeeds(ctx, workCtx context.Context, db *database.Queries, pool *scrapePool, batch int) (tickStats, error) {
+	if ctx.Err() != nil {
+		return tickStats{}, nil
+	}
+
+	feeds, err := db.ClaimFeedsToFetch(ctx, database.ClaimFeedsToFetchParams{
+		LeaseSeconds: int32(feedClaimLease.Seconds()),
+		BatchSize:    int32(batch),
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return tickStats{}, nil
+		}
+		return tickStats{}, fmt.Errorf("failed to get next feeds to fetch: %w", err)
+	}
+	if len(feeds) == 0 {
+		log.Println("No feeds to fetch")
+		return tickStats{}, nil
+	}
+
+	log.Printf("Found %d feeds to fetch", len(feeds))
+	stats := pool.run(ctx, workCtx, feeds)
+	log.Printf("Tick done: %d feeds fetched (%d failed, %d interrupted), %d new posts in %s (%.1f feeds/s)",
+		stats.feeds, stats.failed, stats.interrupted, stats.posts, stats.elapsed.Round(time.Millisecond),
+		float64(stats.feeds)/stats.elapsed.Seconds())
+	return stats, nil
+}
+
+// scrapePool fetches feeds concurrently while capping how many requests hit
+// the same host at once.
+type scrapePool struct {
+	db      *database.Queries
+	cfg     *config.Config
+	workers int
+	perHost int
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+	// backoffs holds hosts that rate limited a fetch during this run, so the
+	// rest of the batch leaves them alone too.
+	backoffs map[string]time.Time
+}
+
+type tickStats struct {
+	feeds       int
+	failed      int
+	interrupted int
+	posts       int
+	elapsed     time.Duration
+}
+
+func (t *tickStats) add(other tickStats) {
+	t.feeds += other.feeds
+	t.failed += other.failed
+	t.interrupted += other.interrupted
+	t.posts += other.posts
+}
+
+func newScrapePool(db *database.Queries, cfg *config.Config, workers, perHost int) *scrapePool {
+	return &scrapePool{
+		db:       db,
+		cfg:      cfg,
+		workers:  workers,
+		perHost:  perHost,
+		hosts:    make(map[string]chan struct{}),
+		backoffs: make(map[string]time.Time),
+	}
+}
+
+// hostSlots returns the semaphore limiting concurrent fetches for the host.
+func (p *scrapePool) hostSlots(host string) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	slots, ok := p.hosts[host]
+	if !ok {
+		slots = make(chan struct{}, p.perHost)
+		p.hosts[host] = slots
+	}
+	return slots
+}
+
+func (p *scrapePool) hostBackoff(host string) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.backoffs[host]
+}
+
+func (p *scrapePool) setHostBackoff(host string, until time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backoffs[host] = until
+}
+
+// run fetches the claimed feeds. Once ctx is done no further feed is started
+// and the claims on the remaining ones are released for another aggregator.
+func (p *scrapePool) run(ctx, workCtx context.Context, feeds []database.Feed) tickStats {
+	start := time.Now()
+	jobs := make(chan database.Feed)
+
+	var mu sync.Mutex
+	stats := tickStats{}
+
+	var wg sync.WaitGroup
+	for range min(p.workers, len(feeds)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for dbFeed := range jobs {
+				host := feedHost(dbFeed.Url)
+				if until := p.hostBackoff(host); time.Now().Before(until) {
+					log.Printf("Skipping feed %s, %s is rate limiting until %s", dbFeed.Name, host, until.Format(time.RFC1123))
+					p.release(dbFeed)
+					continue
+				}
+
+				slots := p.hostSlots(host)
+				slots <- struct{}{}
+				renewed, ok := p.renew(dbFeed)
+				if !ok {
+					<-slots
+					continue
+				}
+				dbFeed = renewed
+				inserted, err := scrapeFeed(workCtx, p.db, p.cfg, dbFeed)
+				<-slots
+
+				if err != nil {
+					log.Println(err)
+				}
+				var rateLimited *feed.RateLimitError
+				if errors.As(err, &rateLimited) {
+					p.setHostBackoff(host, hostRetryAfter(rateLimited))
+				}
+				p.release(dbFeed)
+
+				mu.Lock()
+				stats.feeds++
+				stats.posts += len(inserted)
+				if errors.Is(err, context.Canceled) {
+					stats.interrupted++
+				} else if err != nil {
+					stats.failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i, dbFeed := range feeds {
+		select {
+		case jobs <- dbFeed:
+			continue
+		case <-ctx.Done():
+		}
+		for _, unstarted := range feeds[i:] {
+			p.release(unstarted)
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	stats.elapsed = time.Since(start)
+	return stats
+}
+
+// renew extends the claim on a feed about to be fetched. It reports false
+// when the feed should be skipped, because its lease ran out while it waited
+// and another aggregator claimed it, or because the claim could not be
+// renewed.
+func (p *scrapePool) renew(dbFeed database.Feed) (database.Feed, bool) {
+	renewed, err := p.db.RenewFeedClaim(context.Background(), database.RenewFeedClaimParams{
+		LeaseSeconds: int32(feedClaimLease.Seconds()),
+		ID:           dbFeed.ID,
+		ClaimedUntil: dbFeed.ClaimedUntil,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Skipping feed %s, it was claimed by another aggregator", dbFeed.Name)
+		return database.Feed{}, false
+	}
+	if err != nil {
+		log.Printf("Failed to renew claim on feed %s: %v", dbFeed.Name, err)
+		p.release(dbFeed)
+		return database.Feed{}, false
+	}
+	return renewed, true
+}
+
+// release gives up the claim on a feed. The claim is matched by its expiry,
+// so a claim that lapsed and was taken by another aggregator is left alone.
+func (p *scrapePool) release(dbFeed database.Feed) {
+	if err := releaseFeedClaim(p.db, dbFeed); err != nil {
+		log.Printf("Failed to release claim on feed %s: %v", dbFeed.Name, err)
+	}
+}
+
+func releaseFeedClaim(db *database.Queries, dbFeed database.Feed) error {
+	return db.ReleaseFeedClaim(context.Background(), database.ReleaseFeedClaimParams{
+		ID:           dbFeed.ID,
+		ClaimedUntil: dbFeed.ClaimedUntil,
+	})
+}
+
+// scrapeFeed fetches one feed and stores its items. It returns the new posts
+// stored. Cancelling ctx stops it between items; the bookkeeping
+// below still runs so an interrupted fetch is logged, but it is neither
+// counted as a failure nor rescheduled. cfg supplies the fetch limits.
+func scrapeFeed(ctx context.Context, db *database.Queries, cfg *config.Config, db_feed database.Feed) (inserted []database.Post, err error) {
+	fetchLog := database.CreateFeedFetchParams{
+		ID:        uuid.New(),
+		FeedID:    db_feed.ID,
+		StartedAt: time.Now().UTC(),
+	}
+
+	// The outcome is logged and recorded and the next fetch scheduled on
+	// every exit path, so a failing feed backs off instead of being claimed
+	// again on every tick. Polling hints parsed below update db_feed first.
+	// A feed merged into another one no longer exists and is left alone.
+	merged := false
+	defer func() {
+		if merged {
+			return
+		}
+		fetchLog.FinishedAt = time.Now().UTC()
+		fetchLog.ItemsInserted = int32(len(inserted))
+		if err != nil {
+			fetchLog.Error = sql.NullString{String: err.Error(), Valid: true}
+		}
+		if err := db.CreateFeedFetch(context.Background(), fetchLog); err != nil {
+			log.Printf("Failed to log fetch of feed %s: %v", db_feed.Name, err)
+		}
+
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		// Rate limiting is the host's choice, not a fault of the feed, so it
+		// backs off the whole host without counting towards disabling.
+		var rateLimited *feed.RateLimitError
+		if errors.As(err, &rateLimited) {
+			if err := backOffHost(db, db_feed, rateLimited); err != nil {
+				log.Printf("Failed to back off host of feed %s: %v", db_feed.Name, err)
+			}
+			return
+		}
+		recordFetchOutcome(db, &db_feed, err)
+		if db_feed.DisabledAt.Valid {
+			return
+		}
+		if err := scheduleNextFetch(db, db_feed); err != nil {
+			log.Printf("Failed to schedule next fetch of feed %s: %v", db_feed.Name, err)
+		}
+	}()
+
+	// Items are stored as the feed streams in rather than once the whole
+	// document has been read.
+	fetchedAt := time.Now().UTC()
+	storeFailures := 0
+	storeItem := func(item feed.RSSItem) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		fetchLog.ItemsSeen++
+		post, created, err := storePost(ctx, db, db_feed, item, fetchedAt)
+		if err != nil {
+			log.Printf("Failed to create post: %v", err)
+			storeFailures++
+			return nil
+		}
+		if created {
+			inserted = append(inserted, post)
+			log.Printf("Created post %d: - '%s' from feed '%s'", len(inserted), item.Title, db_feed.Name)
+		}
+		return nil
+	}
+
+	result, err := feed.Fetch(ctx, feed.FetchRequest{
+		URL:            db_feed.Url,
+		ETag:           db_feed.Etag.String,
+		LastModified:   db_feed.LastModified.String,
+		MaxBytes:       cfg.MaxFeedBytes,
+		TrackingParams: cfg.TrackingParams,
+		OnItem:         storeItem,
+	})
+	if result != nil {
+		fetchLog.HttpStatus = sql.NullInt32{Int32: int32(result.StatusCode), Valid: true}
+		fetchLog.Bytes = result.Bytes
+	}
+	if errors.Is(err, context.Canceled) {
+		return inserted, fmt.Errorf("fetch of feed %s interrupted: %w", db_feed.Name, err)
+	}
+	if err != nil {
+		return inserted, fmt.Errorf("failed to collect feed %s: %w", db_feed.Name, err)
+	}
+
+	if result.PermanentRedirect {
+		oldURL := db_feed.Url
+		var moveErr error
+		merged, moveErr = moveFeed(db, &db_feed, result.FinalURL)
+		if moveErr != nil {
+			log.Printf("Failed to move feed %s to %s: %v", db_feed.Name, result.FinalURL, moveErr)
+		} else if merged {
+			log.Printf("Feed '%s' moved from %s to %s, merged into the feed already there", db_feed.Name, oldURL, result.FinalURL)
+			return nil, nil
+		} else {
+			log.Printf("Feed '%s' moved permanently from %s to %s", db_feed.Name, oldURL, result.FinalURL)
+		}
+	}
+
+	if result.NotModified {
+		log.Printf("Feed '%s' not modified since last fetch", db_feed.Name)
+		return nil, nil
+	}
+	feedData := result.Feed
+
+	hints := feedData.PollingHints()
+	db_feed.PublisherIntervalSeconds = int32(hints.MinInterval.Seconds())
+	db_feed.SkipHours = hints.SkipHours
+	db_feed.SkipDays = hints.SkipDays
+	err = db.UpdateFeedPollingHints(ctx, database.UpdateFeedPollingHintsParams{
+		ID:                       db_feed.ID,
+		PublisherIntervalSeconds: db_feed.PublisherIntervalSeconds,
+		SkipHours:                db_feed.SkipHours,
+		SkipDays:                 db_feed.SkipDays,
+	})
+	if err != nil {
+		log.Printf("Failed to save polling hints for feed %s: %v", db_feed.Name, err)
+	}
+
+	metadata := feedData.Metadata()
+	err = db.UpdateFeedMetadata(ctx, database.UpdateFeedMetadataParams{
+		ID:          db_feed.ID,
+		SiteUrl:     metadata.SiteURL,
+		Description: metadata.Description,
+		Language:    metadata.Language,
+		ImageUrl:    metadata.ImageURL,
+	})
+	if err != nil {
+		log.Printf("Failed to save metadata for feed %s: %v", db_feed.Name, err)
+	}
+
+	// Validators are saved only once every item is stored, so a run that
+	// lost items is not mistaken for an up to date one on the next
+	// conditional fetch, which would answer 304 and never send them again.
+	if storeFailures == 0 {
+		err = db.UpdateFeedCacheValidators(ctx, database.UpdateFeedCacheValidatorsParams{
+			ID:           db_feed.ID,
+			Etag:         sql.NullString{String: result.ETag, Valid: result.ETag != ""},
+			LastModified: sql.NullString{String: result.LastModified, Valid: result.LastModified != ""},
+		})
+		if err != nil {
+			log.Printf("Failed to save cache validators for feed %s: %v", db_feed.Name, err)
+		}
+	} else {
+		log.Printf("Not saving cache validators for feed %s, %d posts failed to store", db_feed.Name, storeFailures)
+	}
+
+	if storeFailures > 0 {
+		return inserted, fmt.Errorf("failed to store %d of %d posts from feed %s", storeFailures, fetchLog.ItemsSeen, db_feed.Name)
+	}
+	log.Printf("Feed '%s' collected, %v posts found", db_feed.Name, fetchLog.ItemsSeen)
+	return inserted, nil
+}
+
+// storePost stores one item of the feed. created reports whether it is a new
+// post rather than an update of one stored before.
+func storePost(ctx context.Context, db *database.Queries, db_feed database.Feed, item feed.RSSItem, fetchedAt time.Time) (post database.Post, created bool, err error) {
+	publishedAt, inferred := feed.NormalizeDate(item.PubDate, fetchedAt)
+
+	// Posts stored before guids were tracked, and guid-less posts stored
+	// before links were canonicalized, are keyed on the link as published.
+	// They are moved to the item's key first so the upsert below updates
+	// them instead of storing the item a second time.
+	if legacy := item.LegacyIdentity(); legacy != "" {
+		err := db.RekeyPost(ctx, database.RekeyPostParams{
+			NewGuid: item.Identity(),
+			FeedID:  db_feed.ID,
+			OldGuid: legacy,
+		})
+		if err != nil {
+			return database.Post{}, false, err
+		}
+	}
+
+	// Items already stored are rewritten only when their content hash
+	// changed; unchanged ones come back as sql.ErrNoRows. Rows stored
+	// before hashes existed are backfilled without being marked revised.
+	postID := uuid.New()
+	post, err = db.UpsertPost(ctx, database.UpsertPostParams{
+		ID:        postID,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		FeedID:    db_feed.ID,
+		Title:     item.Title,
+		Description: sql.NullString{
+			String: item.Description,
+			Valid:  true,
+		},
+		Url: item.Link,
+		PublishedAt: sql.NullTime{
+			Time:  publishedAt,
+			Valid: true,
+		},
+		PublishedAtInferred: inferred,
+		Guid:                item.Identity(),
+		ContentHash:         item.ContentHash(),
+		Content:             item.Content,
+		Author:              item.AuthorName(),
+		OriginalUrl:         item.OriginalLink,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// The post itself is unchanged, but its author, media and categories
+		// are refreshed all the same: they are not part of the content hash,
+		// and posts stored before they were tracked have none yet.
+		postID, err := db.GetPostIDByGuid(ctx, database.GetPostIDByGuidParams{
+			FeedID: db_feed.ID,
+			Guid:   item.Identity(),
+		})
+		if err != nil {
+			return database.Post{}, false, err
+		}
+		err = db.UpdatePostAuthor(ctx, database.UpdatePostAuthorParams{
+			ID:     postID,
+			Author: item.AuthorName(),
+		})
+		if err != nil {
+			log.Printf("Failed to save author of post '%s': %v", item.Title, err)
+		}
+		savePostDetails(ctx, db, postID, item)
+		return database.Post{}, false, nil
+	}
+	if err != nil {
+		return database.Post{}, false, err
+	}
+
+	savePostDetails(ctx, db, post.ID, item)
+
+	if post.ID != postID {
+		if post.RevisedAt.Valid {
+			log.Printf("Updated post '%s' from feed '%s'", item.Title, db_feed.Name)
+		}
+		return post, false, nil
+	}
+	return post, true, nil
+}
+
+// savePostDetails stores the media and categories of the item. They are
+// secondary, so failures are logged rather than failing the post.
+func savePostDetails(ctx context.Context, db *database.Queries, postID uuid.UUID, item feed.RSSItem) {
+	if err := saveEnclosures(ctx, db, postID, item); err != nil {
+		log.Printf("Failed to save media of post '%s': %v", item.Title, err)
+	}
+	if err := saveCategories(ctx, db, postID, item); err != nil {
+		log.Printf("Failed to save categories of post '%s': %v", item.Title, err)
+	}
+}
+
+// saveEnclosures stores the media attached to the item. Enclosures already
+// stored for the post are refreshed, and only rewritten when they changed.
+func saveEnclosures(ctx context.Context, db *database.Queries, postID uuid.UUID, item feed.RSSItem) error {
+	episode, hasEpisode := item.EpisodeNumber()
+	for _, media := range item.Media() {
+		err := db.UpsertPostEnclosure(ctx, database.UpsertPostEnclosureParams{
+			ID:              uuid.New(),
+			CreatedAt:       time.Now().UTC(),
+			PostID:          postID,
+			Url:             media.URL,
+			MimeType:        media.Type,
+			Length:          media.Length,
+			DurationSeconds: int32(media.Duration.Seconds()),
+			Episode:         sql.NullInt32{Int32: episode, Valid: hasEpisode},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveCategories replaces the categories stored for the post with the item's,
+// leaving those it already has in place.
+func saveCategories(ctx context.Context, db *database.Queries, postID uuid.UUID, item feed.RSSItem) error {
+	// Not nil, which would reach the query as NULL and delete nothing.
+	names := append([]string{}, item.CategoryNames()...)
+	err := db.DeleteOtherPostCategories(ctx, database.DeleteOtherPostCategoriesParams{
+		PostID: postID,
+		Keep:   names,
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		err := db.AddPostCategory(ctx, database.AddPostCategoryParams{
+			PostID: postID,
+			Name:   name,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveFeed points the feed at the URL it permanently redirected to. When
+// another feed already has that URL both are the same feed, so this one's
+// follows and posts are merged into it and this one is deleted.
+func moveFeed(db *database.Queries, dbFeed *database.Feed, newURL string) (merged bool, err error) {
+	existing, err := db.GetFeedByURL(context.Background(), newURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.UpdateFeedURL(context.Background(), database.UpdateFeedURLParams{
+			ID:  dbFeed.ID,
+			Url: newURL,
+		})
+		if err != nil {
+			return false, err
+		}
+		dbFeed.Url = newURL
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = db.MergeFeedInto(context.Background(), database.MergeFeedIntoParams{
+		TargetID: existing.ID,
+		SourceID: dbFeed.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// recordFetchOutcome stores the result of a fetch on the feed row, disabling
+// the feed once it has failed maxConsecutiveFailures times in a row, or at
+// once when the publisher answered 410 Gone.
+func recordFetchOutcome(db *database.Queries, dbFeed *database.Feed, fetchErr error) {
+	if errors.Is(fetchErr, feed.ErrGone) {
+		updated, err := db.MarkFeedGone(context.Background(), database.MarkFeedGoneParams{
+			LastError: sql.NullString{String: fetchErr.Error(), Valid: true},
+			ID:        dbFeed.ID,
+		})
+		if err != nil {
+			log.Printf("Failed to mark feed %s as gone: %v", dbFeed.Name, err)
+			return
+		}
+		*dbFeed = updated
+		log.Printf("Feed %s is gone and has been disabled, re-enable it with enablefeed if it comes back", dbFeed.Name)
+		return
+	}
+
+	if fetchErr == nil {
+		if err := db.RecordFeedSuccess(context.Background(), dbFeed.ID); err != nil {
+			log.Printf("Failed to record fetch of feed %s: %v", dbFeed.Name, err)
+			return
+		}
+		dbFeed.ConsecutiveFailures = 0
+		return
+	}
+
+	updated, err := db.RecordFeedFailure(context.Background(), database.RecordFeedFailureParams{
+		LastError:    sql.NullString{String: fetchErr.Error(), Valid: true},
+		DisableAfter: maxConsecutiveFailures,
+		ID:           dbFeed.ID,
+	})
+	if err != nil {
+		log.Printf("Failed to record failed fetch of feed %s: %v", dbFeed.Name, err)
+		return
+	}
+	*dbFeed = updated
+
+	if dbFeed.DisabledAt.Valid {
+		log.Printf("Feed %s disabled after %d consecutive failures, re-enable it with enablefeed", dbFeed.Name, dbFeed.ConsecutiveFailures)
+	}
+}
+
+func HandlerFetch(s *State, cmd Command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <url_or_name_of_feed>", cmd.Name)
+	}
+
+	dbFeed, err := findFeed(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+
+	backoff, err := s.Db.GetHostBackoff(s.Ctx, feedHost(dbFeed.Url))
+	if err == nil {
+		return fmt.Errorf("%s asked not to be contacted before %s", backoff.Host, backoff.RetryAfter.Format(time.RFC1123))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check host backoff: %w", err)
+	}
+
+	// Claim the feed like agg does so a running aggregator does not fetch it
+	// at the same time.
+	dbFeed, err = s.Db.ClaimFeed(s.Ctx, database.ClaimFeedParams{
+		LeaseSeconds: int32(feedClaimLease.Seconds()),
+		ID:           dbFeed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("feed is being fetched by an aggregator, try again shortly")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to claim feed: %w", err)
+	}
+	defer func() {
+		if err := releaseFeedClaim(s.Db, dbFeed); err != nil {
+			log.Printf("Failed to release claim on feed %s: %v", dbFeed.Name, err)
+		}
+	}()
+
+	// Posts stored before an error are still listed.
+	inserted, err := scrapeFeed(s.Ctx, s.Db, s.Cfg, dbFeed)
+	if err != nil && len(inserted) == 0 {
+		return err
+	}
+
+	fmt.Printf("%d new posts from %s:\n", len(inserted), dbFeed.Name)
+	for _, post := range inserted {
+		fmt.Printf("* %s\n", post.Title)
+		fmt.Printf("  %s\n", post.Url)
+	}
+
+	return err
+}
+
+// findFeed looks a feed up by URL, falling back to its name.
+func findFeed(s *State, urlOrName string) (database.Feed, error) {
+	dbFeed, err := s.Db.GetFeedByURL(s.Ctx, urlOrName)
+	if err == nil {
+		return dbFeed, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, fmt.Errorf("faild to get feed: %w", err)
+	}
+
+	feeds, err := s.Db.GetFeedsByName(s.Ctx, urlOrName)
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("faild to get feed: %w", err)
+	}
+	switch len(feeds) {
+	case 0:
+		return database.Feed{}, fmt.Errorf("no feed with URL or name %q", urlOrName)
+	case 1:
+		return feeds[0], nil
+	default:
+		return database.Feed{}, fmt.Errorf("%d feeds are named %q, use the URL instead", len(feeds), urlOrName)
+	}
+}
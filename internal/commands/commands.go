@@ -4,8 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -13,7 +14,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/thedevscott/blogaggregator/internal/config"
 	"github.com/thedevscott/blogaggregator/internal/database"
-	"github.com/thedevscott/blogaggregator/internal/feed"
+	"github.com/thedevscott/blogaggregator/internal/render"
 )
 
 type Command struct {
@@ -28,6 +29,8 @@ type Commands struct {
 type State struct {
 	Db  *database.Queries
 	Cfg *config.Config
+	// Ctx is cancelled when the process receives SIGINT or SIGTERM.
+	Ctx context.Context
 }
 
 func (c *Commands) Register(name string, f func(*State, Command) error) {
@@ -77,10 +80,20 @@ func HandlerLogin(s *State, cmd Command) error {
 }
 
 func HandlerBrowse(s *State, cmd Command, user database.User) error {
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	podcasts := flags.Bool("podcasts", false, "only show posts with audio or video")
+	full := flags.Bool("full", false, "show the full body of posts instead of their summary")
+	author := flags.String("author", "", "only show posts whose author contains this")
+	category := flags.String("category", "", "only show posts in this category")
+	raw := flags.Bool("raw", false, "print post bodies as the feed sent them, HTML and all")
+	if err := flags.Parse(cmd.Args); err != nil || flags.NArg() > 1 {
+		return fmt.Errorf("usage: %s [--podcasts] [--full] [--raw] [--author X] [--category Y] [limit]", cmd.Name)
+	}
+
 	limit := 2
 
-	if len(cmd.Args) == 1 {
-		if specifiedLimit, err := strconv.Atoi(cmd.Args[0]); err == nil {
+	if flags.NArg() == 1 {
+		if specifiedLimit, err := strconv.Atoi(flags.Arg(0)); err == nil {
 			limit = specifiedLimit
 		} else {
 			return fmt.Errorf("invalid limit: %w", err)
@@ -88,26 +101,226 @@ func HandlerBrowse(s *State, cmd Command, user database.User) error {
 	}
 
 	posts, err := s.Db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  int32(limit),
+		UserID:       user.ID,
+		PodcastsOnly: *podcasts,
+		Author:       *author,
+		Category:     *category,
+		Limit:        int32(limit),
 	})
 
 	if err != nil {
 		return fmt.Errorf("failed to get posts for user: %w", err)
 	}
 
+	postIDs := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+	enclosures, err := s.Db.GetEnclosuresForPosts(context.Background(), postIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get post media: %w", err)
+	}
+	enclosuresByPost := make(map[uuid.UUID][]database.PostEnclosure)
+	for _, enclosure := range enclosures {
+		enclosuresByPost[enclosure.PostID] = append(enclosuresByPost[enclosure.PostID], enclosure)
+	}
+
+	categories, err := s.Db.GetCategoriesForPosts(context.Background(), postIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get post categories: %w", err)
+	}
+	categoriesByPost := make(map[uuid.UUID][]string)
+	for _, category := range categories {
+		categoriesByPost[category.PostID] = append(categoriesByPost[category.PostID], category.Name)
+	}
+
 	fmt.Printf("Found %d posts for user %s:\n", len(posts), user.Name)
 	for _, post := range posts {
-		fmt.Printf("%s from %s\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName)
-		fmt.Printf("--- %s ---\n", post.Title)
-		fmt.Printf("    %v\n", post.Description.String)
-		fmt.Printf("Link: %s\n", post.Url)
+		published := post.PublishedAt.Time.Format("Mon Jan 2")
+		if post.PublishedAtInferred {
+			published += " (date unknown, first seen)"
+		}
+		if post.RevisedAt.Valid {
+			published += fmt.Sprintf(" (updated %s)", post.RevisedAt.Time.Format("Mon Jan 2"))
+		}
+		if post.Author != "" {
+			fmt.Printf("%s from %s by %s\n", published, render.Plain(post.FeedName), render.Plain(post.Author))
+		} else {
+			fmt.Printf("%s from %s\n", published, render.Plain(post.FeedName))
+		}
+		fmt.Printf("--- %s ---\n", render.Plain(post.Title))
+		fmt.Println(formatBody(postBody(post.Description, post.Content, *full), post.Url, *raw, "    "))
+		fmt.Printf("Link: %s\n", render.Plain(post.Url))
+		if postCategories := categoriesByPost[post.ID]; len(postCategories) > 0 {
+			for i, name := range postCategories {
+				postCategories[i] = render.Plain(name)
+			}
+			fmt.Printf("Categories: %s\n", strings.Join(postCategories, ", "))
+		}
+		printEnclosures(enclosuresByPost[post.ID])
+		fmt.Printf("Read: %s\n", post.ID)
 		fmt.Println("=====================================")
 	}
 
 	return nil
 }
 
+// HandlerCategories lists the most common categories of posts in the feeds
+// the user follows.
+func HandlerCategories(s *State, cmd Command, user database.User) error {
+	limit := 20
+	if len(cmd.Args) == 1 {
+		specifiedLimit, err := strconv.Atoi(cmd.Args[0])
+		if err != nil || specifiedLimit < 1 {
+			return fmt.Errorf("invalid limit: %s", cmd.Args[0])
+		}
+		limit = specifiedLimit
+	} else if len(cmd.Args) > 1 {
+		return fmt.Errorf("usage: %s [limit]", cmd.Name)
+	}
+
+	categories, err := s.Db.GetTopCategoriesForUser(context.Background(), database.GetTopCategoriesForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	if len(categories) == 0 {
+		fmt.Println("No categories found in followed feeds.")
+		return nil
+	}
+
+	fmt.Printf("Top %d categories for user %s:\n", len(categories), user.Name)
+	for _, category := range categories {
+		fmt.Printf("* %s (%d posts)\n", render.Plain(category.Name), category.PostCount)
+	}
+
+	return nil
+}
+
+// HandlerRead shows one post, by default with its full body.
+func HandlerRead(s *State, cmd Command) error {
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	summary := flags.Bool("summary", false, "show the summary instead of the full body")
+	raw := flags.Bool("raw", false, "print the body as the feed sent it, HTML and all")
+	if err := flags.Parse(cmd.Args); err != nil || flags.NArg() != 1 {
+		return fmt.Errorf("usage: %s [--summary] [--raw] <post-id>", cmd.Name)
+	}
+
+	postID, err := uuid.Parse(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid post id: %w", err)
+	}
+
+	post, err := s.Db.GetPostById(context.Background(), postID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no post with id %s", postID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get post: %w", err)
+	}
+
+	enclosures, err := s.Db.GetEnclosuresForPosts(context.Background(), []uuid.UUID{post.ID})
+	if err != nil {
+		return fmt.Errorf("failed to get post media: %w", err)
+	}
+
+	fmt.Printf("--- %s ---\n", render.Plain(post.Title))
+	if post.Author != "" {
+		fmt.Printf("By: %s\n", render.Plain(post.Author))
+	}
+	if post.PublishedAt.Valid && !post.PublishedAtInferred {
+		fmt.Printf("Published: %s\n", post.PublishedAt.Time.Format("Mon Jan 2 2006"))
+	}
+	fmt.Printf("Link: %s\n", render.Plain(post.Url))
+	if post.OriginalUrl != "" && post.OriginalUrl != post.Url {
+		fmt.Printf("Original link: %s\n", render.Plain(post.OriginalUrl))
+	}
+	printEnclosures(enclosures)
+	fmt.Println()
+	fmt.Println(formatBody(postBody(post.Description, post.Content, !*summary), post.Url, *raw, ""))
+
+	return nil
+}
+
+// postBody picks what to show of a post: its full body when wanted and the
+// feed supplied one, otherwise its summary.
+func postBody(description sql.NullString, content string, full bool) string {
+	if full && content != "" {
+		return content
+	}
+	if description.String == "" {
+		return content
+	}
+	return description.String
+}
+
+// formatBody prepares a post body for the terminal: the HTML is rendered as
+// text wrapped to the terminal, with links footnoted and resolved against the
+// post URL. raw skips rendering, though not sanitizing. Every line is
+// prefixed with indent.
+func formatBody(body, postURL string, raw bool, indent string) string {
+	if raw {
+		body = render.Sanitize(body)
+	} else {
+		body = render.HTML(body, postURL).Text(terminalWidth() - len(indent))
+	}
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// terminalWidth returns the width set in $COLUMNS, or 80.
+func terminalWidth() int {
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
+
+func printEnclosures(enclosures []database.PostEnclosure) {
+	if len(enclosures) > 0 && enclosures[0].Episode.Valid {
+		fmt.Printf("Episode: %d\n", enclosures[0].Episode.Int32)
+	}
+	for _, enclosure := range enclosures {
+		var details []string
+		if enclosure.MimeType != "" {
+			details = append(details, render.Plain(enclosure.MimeType))
+		}
+		if enclosure.DurationSeconds > 0 {
+			details = append(details, (time.Duration(enclosure.DurationSeconds) * time.Second).String())
+		}
+		if enclosure.Length > 0 {
+			details = append(details, formatBytes(enclosure.Length))
+		}
+
+		fmt.Printf("Media: %s", render.Plain(enclosure.Url))
+		if len(details) > 0 {
+			fmt.Printf(" (%s)", strings.Join(details, ", "))
+		}
+		fmt.Println()
+	}
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
 func HandlerRegister(s *State, cmd Command) error {
 	if len(cmd.Args) != 1 {
 		return fmt.Errorf("usage: %s <name>", cmd.Name)
@@ -161,103 +374,46 @@ func HandlerGetUsers(s *State, cmd Command) error {
 	return nil
 }
 
-func HandlerAggregate(s *State, cmd Command) error {
-	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
-		return fmt.Errorf("usage: %v <delay_between_requests>", cmd.Name)
-	}
-
-	timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
-	if err != nil {
-		return fmt.Errorf("invalid delay duration: %w", err)
-	}
-
-	log.Printf("Collecting feeds ever %s...", timeBetweenRequests)
-
-	ticker := time.NewTicker(timeBetweenRequests)
-
-	for ; ; <-ticker.C {
-		scrapeFeeds(s)
-	}
-}
-
-func scrapeFeeds(s *State) {
-	feed, err := s.Db.GetNextFeedToFetch(context.Background())
-	if err != nil {
-		log.Println("failed to get next feeds to fetch", err)
-	}
-	log.Printf("Found a feed to fetch: %s\n", feed.Name)
-	scrapeFeed(s.Db, feed)
-}
+func HandlerAddFeed(s *State, cmd Command, user database.User) error {
 
-func scrapeFeed(db *database.Queries, db_feed database.Feed) {
-	_, err := db.MarkFeedFetched(context.Background(), db_feed.ID)
-	if err != nil {
-		log.Printf("Failed to mark feed %s fetched: %v", db_feed.Name, err)
-		return
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		return fmt.Errorf("usage: %s [name] <url>", cmd.Name)
 	}
 
-	feedData, err := feed.FetchFeed(context.Background(), db_feed.Url)
+	url, feedData, err := discoverFeedURL(s, cmd.Args[len(cmd.Args)-1])
 	if err != nil {
-		log.Printf("Failed to collect feed %s: %v", db_feed.Name, err)
-		return
+		return err
 	}
-
-	count := 1
-	for _, item := range feedData.Channel.Item {
-
-		publishedAt := sql.NullTime{}
-		if t, err := time.Parse(time.RFC1123Z, item.PubDate); err == nil {
-			publishedAt = sql.NullTime{
-				Time:  t,
-				Valid: true,
-			}
-		}
-
-		_, err = db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:        uuid.New(),
-			CreatedAt: time.Now().UTC(),
-			UpdatedAt: time.Now().UTC(),
-			FeedID:    db_feed.ID,
-			Title:     item.Title,
-			Description: sql.NullString{
-				String: item.Description,
-				Valid:  true,
-			},
-			Url:         item.Link,
-			PublishedAt: publishedAt,
-		})
-
+	// A feed only linked from the page has not been downloaded yet.
+	if feedData == nil {
+		feedData, err = validateFeed(s, url)
 		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-				continue
-			}
-			log.Printf("Failed to create post: %v", err)
-			continue
+			return err
 		}
-
-		log.Printf("Created post %d of %d: - '%s' from feed '%s'", count, len(feedData.Channel.Item), item.Title, db_feed.Name)
-		count++
 	}
 
-	log.Printf("Feed '%s' collected, %v posts found", db_feed.Name, len(feedData.Channel.Item))
-}
-
-func HandlerAddFeed(s *State, cmd Command, user database.User) error {
-
-	if len(cmd.Args) != 2 {
-		return fmt.Errorf("usage: %s <name> <url>", cmd.Name)
+	var name string
+	if len(cmd.Args) == 2 {
+		name = cmd.Args[0]
+	} else {
+		name = strings.TrimSpace(feedData.Channel.Title)
+		if name == "" {
+			return fmt.Errorf("the feed has no title, name it: %s <name> <url>", cmd.Name)
+		}
 	}
 
-	name := cmd.Args[0]
-	url := cmd.Args[1]
-
+	metadata := feedData.Metadata()
 	feed, err := s.Db.CreateFeed(context.Background(), database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		UserID:    user.ID,
-		Name:      name,
-		Url:       url,
+		ID:          uuid.New(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		UserID:      user.ID,
+		Name:        name,
+		Url:         url,
+		SiteUrl:     metadata.SiteURL,
+		Description: metadata.Description,
+		Language:    metadata.Language,
+		ImageUrl:    metadata.ImageURL,
 	})
 
 	if err != nil {
@@ -285,6 +441,13 @@ func HandlerAddFeed(s *State, cmd Command, user database.User) error {
 }
 
 func HandlerGetFeed(s *State, cmd Command) error {
+	if len(cmd.Args) == 1 && cmd.Args[0] == "--errors" {
+		return printFeedErrors(s)
+	}
+	if len(cmd.Args) != 0 {
+		return fmt.Errorf("usage: %s [--errors]", cmd.Name)
+	}
+
 	feeds, err := s.Db.GetFeeds(context.Background())
 	if err != nil {
 		return fmt.Errorf("failed to get feeds: %w", err)
@@ -307,6 +470,157 @@ func HandlerGetFeed(s *State, cmd Command) error {
 		fmt.Printf("Feed Name: %s\n", feed.Name)
 		fmt.Printf("Feed URL: %s\n", feed.Url)
 		fmt.Printf("Feed User: %s\n", user.Name)
+		if feed.SiteUrl != "" {
+			fmt.Printf("Feed Site: %s\n", feed.SiteUrl)
+		}
+		if feed.Description != "" {
+			fmt.Printf("Feed Description: %s\n", feed.Description)
+		}
+		if feed.Language != "" {
+			fmt.Printf("Feed Language: %s\n", feed.Language)
+		}
+		if feed.ImageUrl != "" {
+			fmt.Printf("Feed Image: %s\n", feed.ImageUrl)
+		}
+		if feed.NextFetchAt.Valid {
+			fmt.Printf("Feed Next Fetch: %s\n", feed.NextFetchAt.Time.Format(time.RFC1123))
+		} else {
+			fmt.Println("Feed Next Fetch: due now")
+		}
+		if feed.FetchIntervalSeconds.Valid {
+			fmt.Printf("Feed Fetch Interval: %s\n", time.Duration(feed.FetchIntervalSeconds.Int32)*time.Second)
+		}
+		if feed.DisabledAt.Valid {
+			fmt.Println("Feed Disabled: see feeds --errors")
+		}
+	}
+
+	return nil
+}
+
+func printFeedErrors(s *State) error {
+	feeds, err := s.Db.GetFeedsWithErrors(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get feeds: %w", err)
+	}
+
+	if len(feeds) == 0 {
+		fmt.Println("No failing feeds.")
+		return nil
+	}
+
+	fmt.Printf("Failing feeds found: %d\n", len(feeds))
+
+	for _, feed := range feeds {
+		fmt.Printf("Feed Name: %s\n", feed.Name)
+		fmt.Printf("Feed URL: %s\n", feed.Url)
+		fmt.Printf("Feed Failures: %d\n", feed.ConsecutiveFailures)
+		fmt.Printf("Feed Last Error: %s\n", feed.LastError.String)
+		if feed.DisabledAt.Valid {
+			fmt.Printf("Feed Disabled: %s\n", feed.DisabledAt.Time.Format(time.RFC1123))
+		} else if feed.NextFetchAt.Valid {
+			fmt.Printf("Feed Next Retry: %s\n", feed.NextFetchAt.Time.Format(time.RFC1123))
+		}
+	}
+
+	return nil
+}
+
+func HandlerEnableFeed(s *State, cmd Command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <url_of_feed>", cmd.Name)
+	}
+
+	url := cmd.Args[0]
+
+	feed, err := s.Db.EnableFeed(context.Background(), url)
+	if err != nil {
+		return fmt.Errorf("failed to enable feed: %w", err)
+	}
+
+	fmt.Printf("%s enabled, it will be fetched on the next tick.\n", feed.Name)
+	return nil
+}
+
+func HandlerFetchLog(s *State, cmd Command) error {
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	limit := flags.Int("limit", 10, "number of fetches to show")
+	usage := fmt.Errorf("usage: %s <url_of_feed> [--limit N]", cmd.Name)
+
+	if len(cmd.Args) < 1 {
+		return usage
+	}
+	url := cmd.Args[0]
+	if err := flags.Parse(cmd.Args[1:]); err != nil || flags.NArg() != 0 || *limit < 1 {
+		return usage
+	}
+
+	feed, err := s.Db.GetFeedByURL(context.Background(), url)
+	if err != nil {
+		return fmt.Errorf("faild to get feed: %w", err)
+	}
+
+	fetches, err := s.Db.GetFeedFetchesForFeed(context.Background(), database.GetFeedFetchesForFeedParams{
+		FeedID: feed.ID,
+		Limit:  int32(*limit),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get fetch log: %w", err)
+	}
+
+	if len(fetches) == 0 {
+		fmt.Printf("No fetches logged for %s.\n", feed.Name)
+		return nil
+	}
+
+	fmt.Printf("Last %d fetches of %s:\n", len(fetches), feed.Name)
+	for _, fetch := range fetches {
+		status := "-"
+		if fetch.HttpStatus.Valid {
+			status = strconv.Itoa(int(fetch.HttpStatus.Int32))
+		}
+		fmt.Printf("* %s (%s) status %s, %d bytes, %d items, %d new\n",
+			fetch.StartedAt.Format(time.RFC1123), fetch.FinishedAt.Sub(fetch.StartedAt).Round(time.Millisecond),
+			status, fetch.Bytes, fetch.ItemsSeen, fetch.ItemsInserted)
+		if fetch.Error.Valid {
+			fmt.Printf("    error: %s\n", fetch.Error.String)
+		}
+	}
+
+	return nil
+}
+
+func HandlerSetInterval(s *State, cmd Command) error {
+	if len(cmd.Args) != 2 {
+		return fmt.Errorf("usage: %s <url_of_feed> <interval|auto>", cmd.Name)
+	}
+
+	url := cmd.Args[0]
+
+	interval := sql.NullInt32{}
+	if cmd.Args[1] != "auto" {
+		d, err := time.ParseDuration(cmd.Args[1])
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		if d < time.Minute {
+			return errors.New("interval must be at least 1m")
+		}
+		interval = sql.NullInt32{Int32: int32(d.Seconds()), Valid: true}
+	}
+
+	feed, err := s.Db.SetFeedFetchInterval(context.Background(), database.SetFeedFetchIntervalParams{
+		Url:                  url,
+		FetchIntervalSeconds: interval,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set fetch interval: %w", err)
+	}
+
+	if interval.Valid {
+		fmt.Printf("%s will be fetched every %s.\n", feed.Name, time.Duration(interval.Int32)*time.Second)
+	} else {
+		fmt.Printf("%s will be fetched on an adaptive schedule.\n", feed.Name)
 	}
 
 	return nil
@@ -320,6 +634,17 @@ func HandlerFollow(s *State, cmd Command, user database.User) error {
 	url := cmd.Args[0]
 
 	feed, err := s.Db.GetFeedByURL(context.Background(), url)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Not a tracked feed URL, but it may be the site of one.
+		url, _, err = discoverFeedURL(s, url)
+		if err != nil {
+			return err
+		}
+		feed, err = s.Db.GetFeedByURL(context.Background(), url)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s is not tracked yet, add it with addfeed", url)
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("faild to get feed: %w", err)
 	}
@@ -398,6 +723,10 @@ func printFeed(feed database.Feed, user database.User) {
 	fmt.Printf("* Name:          %s\n", feed.Name)
 	fmt.Printf("* URL:           %s\n", feed.Url)
 	fmt.Printf("* User:          %s\n", user.Name)
+	fmt.Printf("* Site:          %s\n", feed.SiteUrl)
+	fmt.Printf("* Description:   %s\n", feed.Description)
+	fmt.Printf("* Language:      %s\n", feed.Language)
+	fmt.Printf("* Image:         %s\n", feed.ImageUrl)
 	fmt.Printf("* LastFetchedAt: %s\n", feed.LastFetchedAt.Time)
 }
 
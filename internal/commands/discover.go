@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thedevscott/blogaggregator/internal/feed"
+)
+
+// feedRequest is a fetch of feedURL within the configured limits.
+func feedRequest(s *State, feedURL string) feed.FetchRequest {
+	return feed.FetchRequest{
+		URL:            feedURL,
+		MaxBytes:       s.Cfg.MaxFeedBytes,
+		TrackingParams: s.Cfg.TrackingParams,
+	}
+}
+
+// validateFeed fetches the feed once, so a URL that does not serve a usable
+// feed is refused up front rather than failing on every aggregation.
+func validateFeed(s *State, feedURL string) (*feed.RSSFeed, error) {
+	result, err := feed.Fetch(s.Ctx, feedRequest(s, feedURL))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a usable feed: %w", feedURL, err)
+	}
+	return result.Feed, nil
+}
+
+// discoverFeedURL resolves what the user pasted, often a site's homepage, to
+// the URL of its feed. When the page links several feeds they are listed for
+// the user to pick from rather than guessed between. The feed is returned too
+// when it was already downloaded while looking for it, and is nil otherwise.
+func discoverFeedURL(s *State, pageURL string) (string, *feed.RSSFeed, error) {
+	candidates, result, err := feed.Discover(s.Ctx, feedRequest(s, pageURL))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to look for a feed at %s: %w", pageURL, err)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", nil, fmt.Errorf("no feed found at %s", pageURL)
+	case 1:
+		if candidates[0] != pageURL {
+			fmt.Printf("Found feed %s\n", candidates[0])
+		}
+		if result != nil {
+			return candidates[0], result.Feed, nil
+		}
+		return candidates[0], nil, nil
+	default:
+		fmt.Printf("%s links %d feeds:\n", pageURL, len(candidates))
+		for _, candidate := range candidates {
+			fmt.Printf("* %s\n", candidate)
+		}
+		return "", nil, errors.New("run the command again with the URL of the feed you want")
+	}
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/thedevscott/blogaggregator/internal/database"
+	"github.com/thedevscott/blogaggregator/internal/feed"
+)
+
+const (
+	minFetchInterval = 15 * time.Minute
+	maxFetchInterval = 24 * time.Hour
+	// postingWindow is how far back posts are counted to estimate how often
+	// a feed publishes.
+	postingWindow = 30 * 24 * time.Hour
+
+	// retryBaseDelay is the wait after the first failed fetch. It doubles
+	// with each further failure up to maxFetchInterval.
+	retryBaseDelay = 5 * time.Minute
+	// maxConsecutiveFailures is how many failed fetches in a row disable a
+	// feed until it is re-enabled by hand.
+	maxConsecutiveFailures = 10
+
+	// defaultHostBackoff is how long a host that rate limited a fetch is left
+	// alone when it did not send a usable Retry-After.
+	defaultHostBackoff = 10 * time.Minute
+)
+
+// feedHost returns the lower-cased host name of the feed URL. It has to agree
+// with the host extracted by the ClaimFeedsToFetch query.
+func feedHost(feedURL string) string {
+	u, err := url.Parse(feedURL)
+	if err != nil || u.Hostname() == "" {
+		return feedURL
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// hostRetryAfter is when a rate limited host may be contacted again: its
+// Retry-After if it sent a sensible one, capped at maxFetchInterval.
+func hostRetryAfter(rateLimited *feed.RateLimitError) time.Time {
+	now := time.Now().UTC()
+	if rateLimited.RetryAfter.Before(now) {
+		return now.Add(defaultHostBackoff)
+	}
+	if latest := now.Add(maxFetchInterval); rateLimited.RetryAfter.After(latest) {
+		return latest
+	}
+	return rateLimited.RetryAfter
+}
+
+// backOffHost records that no feed on the host may be fetched before its
+// retry time, and moves this feed's next fetch there.
+func backOffHost(db *database.Queries, dbFeed database.Feed, rateLimited *feed.RateLimitError) error {
+	retryAfter := hostRetryAfter(rateLimited)
+
+	err := db.SetHostBackoff(context.Background(), database.SetHostBackoffParams{
+		Host:       feedHost(dbFeed.Url),
+		RetryAfter: retryAfter,
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.SetFeedNextFetchAt(context.Background(), database.SetFeedNextFetchAtParams{
+		ID:          dbFeed.ID,
+		NextFetchAt: sql.NullTime{Time: retryAfter, Valid: true},
+	})
+}
+
+// fetchInterval picks how long to wait before polling the feed again. A
+// manual override wins outright. Otherwise the feed is polled about twice per
+// observed posting interval, never more often than the publisher's own hints
+// allow, and always within minFetchInterval and maxFetchInterval; a monthly
+// sy:updatePeriod still gets checked daily.
+func fetchInterval(dbFeed database.Feed, recentPosts int64) time.Duration {
+	if dbFeed.FetchIntervalSeconds.Valid {
+		return time.Duration(dbFeed.FetchIntervalSeconds.Int32) * time.Second
+	}
+
+	interval := maxFetchInterval
+	if recentPosts > 0 {
+		interval = postingWindow / time.Duration(recentPosts) / 2
+	}
+	publisherInterval := time.Duration(dbFeed.PublisherIntervalSeconds) * time.Second
+	interval = max(interval, publisherInterval)
+	return min(max(interval, minFetchInterval), maxFetchInterval)
+}
+
+// retryDelay is the exponential backoff after the given number of
+// consecutive failed fetches.
+func retryDelay(failures int32) time.Duration {
+	delay := retryBaseDelay
+	for i := int32(1); i < failures && delay < maxFetchInterval; i++ {
+		delay *= 2
+	}
+	return min(delay, maxFetchInterval)
+}
+
+// nextFetchTime returns the first time at or after from that is not in one of
+// the feed's skipped hours or days.
+func nextFetchTime(from time.Time, skipHours, skipDays int32) time.Time {
+	next := from.UTC()
+	// A week of hours covers every combination; if every hour is skipped the
+	// hints are nonsense and are ignored.
+	for range 7 * 24 {
+		if skipHours&(1<<next.Hour()) == 0 && skipDays&(1<<next.Weekday()) == 0 {
+			return next
+		}
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return from.UTC()
+}
+
+// scheduleNextFetch sets when the feed becomes due again based on its
+// posting frequency and the publisher's hints, or on the backoff when the
+// last fetch failed.
+func scheduleNextFetch(db *database.Queries, dbFeed database.Feed) error {
+	now := time.Now().UTC()
+
+	if dbFeed.ConsecutiveFailures > 0 {
+		next := nextFetchTime(now.Add(retryDelay(dbFeed.ConsecutiveFailures)), dbFeed.SkipHours, dbFeed.SkipDays)
+		return db.SetFeedNextFetchAt(context.Background(), database.SetFeedNextFetchAtParams{
+			ID:          dbFeed.ID,
+			NextFetchAt: sql.NullTime{Time: next, Valid: true},
+		})
+	}
+
+	recentPosts, err := db.CountRecentPostsForFeed(context.Background(), database.CountRecentPostsForFeedParams{
+		FeedID:      dbFeed.ID,
+		PublishedAt: sql.NullTime{Time: now.Add(-postingWindow), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	next := nextFetchTime(now.Add(fetchInterval(dbFeed, recentPosts)), dbFeed.SkipHours, dbFeed.SkipDays)
+
+	return db.SetFeedNextFetchAt(context.Background(), database.SetFeedNextFetchAtParams{
+		ID:          dbFeed.ID,
+		NextFetchAt: sql.NullTime{Time: next, Valid: true},
+	})
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/thedevscott/blogaggregator/internal/database"
+	"github.com/thedevscott/blogaggregator/internal/feed"
+)
+
+func TestFetchInterval(t *testing.T) {
+	tests := []struct {
+		name              string
+		override          time.Duration
+		publisherInterval time.Duration
+		recentPosts       int64
+		want              time.Duration
+	}{
+		{name: "no posts", want: maxFetchInterval},
+		{name: "busy feed hits the floor", recentPosts: 10000, want: minFetchInterval},
+		{name: "twice per posting interval", recentPosts: 30, want: 12 * time.Hour},
+		{name: "ttl slows polling", recentPosts: 10000, publisherInterval: time.Hour, want: time.Hour},
+		{name: "monthly update period is capped", recentPosts: 30, publisherInterval: 30 * 24 * time.Hour, want: maxFetchInterval},
+		{name: "yearly update period is capped", publisherInterval: 365 * 24 * time.Hour, want: maxFetchInterval},
+		{name: "override wins", override: 48 * time.Hour, publisherInterval: time.Hour, want: 48 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbFeed := database.Feed{PublisherIntervalSeconds: int32(tt.publisherInterval.Seconds())}
+			if tt.override > 0 {
+				dbFeed.FetchIntervalSeconds = sql.NullInt32{Int32: int32(tt.override.Seconds()), Valid: true}
+			}
+			if got := fetchInterval(dbFeed, tt.recentPosts); got != tt.want {
+				t.Errorf("fetchInterval = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		none       bool
+		want       time.Duration
+	}{
+		{name: "no retry after", none: true, want: defaultHostBackoff},
+		{name: "in the past", retryAfter: -time.Hour, want: defaultHostBackoff},
+		{name: "within a day", retryAfter: 2 * time.Hour, want: 2 * time.Hour},
+		{name: "more than a day is capped", retryAfter: 72 * time.Hour, want: maxFetchInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().UTC()
+			rateLimited := &feed.RateLimitError{StatusCode: 429}
+			if !tt.none {
+				rateLimited.RetryAfter = now.Add(tt.retryAfter)
+			}
+			got := hostRetryAfter(rateLimited).Sub(now)
+			if diff := got - tt.want; diff < 0 || diff > time.Second {
+				t.Errorf("hostRetryAfter is %s from now, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextFetchTime(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2024, 3, 6, 10, 30, 0, 0, time.UTC)
+	allHours := int32(1<<24 - 1)
+	allDays := int32(1<<7 - 1)
+
+	tests := []struct {
+		name      string
+		from      time.Time
+		skipHours int32
+		skipDays  int32
+		want      time.Time
+	}{
+		{name: "no hints", from: from, want: from},
+		{name: "hour not skipped", from: from, skipHours: 1 << 11, want: from},
+		{name: "skipped hour moves to the next hour", from: from, skipHours: 1 << 10, want: time.Date(2024, 3, 6, 11, 0, 0, 0, time.UTC)},
+		{name: "run of skipped hours", from: from, skipHours: 1<<10 | 1<<11 | 1<<12, want: time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC)},
+		{name: "skipped hours wrap past midnight", from: time.Date(2024, 3, 6, 23, 5, 0, 0, time.UTC), skipHours: 1<<23 | 1<<0, want: time.Date(2024, 3, 7, 1, 0, 0, 0, time.UTC)},
+		{name: "skipped day moves to the next day", from: from, skipDays: 1 << time.Wednesday, want: time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{name: "weekend skipped", from: time.Date(2024, 3, 9, 8, 0, 0, 0, time.UTC), skipDays: 1<<time.Saturday | 1<<time.Sunday, want: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{name: "skipped hours and days", from: from, skipHours: 1 << 0, skipDays: 1 << time.Wednesday, want: time.Date(2024, 3, 7, 1, 0, 0, 0, time.UTC)},
+		{name: "every hour skipped is ignored", from: from, skipHours: allHours, want: from},
+		{name: "every day skipped is ignored", from: from, skipDays: allDays, want: from},
+		{name: "other zones are taken in UTC", from: time.Date(2024, 3, 6, 5, 30, 0, 0, time.FixedZone("EST", -5*3600)), skipHours: 1 << 10, want: time.Date(2024, 3, 6, 11, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextFetchTime(tt.from, tt.skipHours, tt.skipDays)
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("nextFetchTime = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		failures int32
+		want     time.Duration
+	}{
+		{0, retryBaseDelay},
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{3, 20 * time.Minute},
+		{5, 80 * time.Minute},
+		{9, 1280 * time.Minute},
+		{10, maxFetchInterval},
+		{30, maxFetchInterval},
+		{1 << 30, maxFetchInterval},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.failures); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
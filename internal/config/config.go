@@ -11,6 +11,13 @@ const configFilename = ".gatorconfig.json"
 type Config struct {
 	DBURL           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	// MaxFeedBytes caps the size of a fetched feed. Zero uses the feed
+	// package default.
+	MaxFeedBytes int64 `json:"max_feed_bytes,omitempty"`
+	// TrackingParams are the query parameters stripped from post links. A
+	// trailing * matches any suffix. Unset uses the feed package default,
+	// an empty list strips nothing.
+	TrackingParams []string `json:"tracking_params"`
 }
 
 func Read() (Config, error) {
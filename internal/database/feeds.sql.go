@@ -7,24 +7,145 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const claimFeed = `-- name: ClaimFeed :one
+UPDATE feeds
+SET claimed_until = NOW() + ($1::int * INTERVAL '1 second')
+WHERE id = $2
+AND (claimed_until IS NULL OR claimed_until < NOW())
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
+`
+
+type ClaimFeedParams struct {
+	LeaseSeconds int32
+	ID           uuid.UUID
+}
+
+func (q *Queries) ClaimFeed(ctx context.Context, arg ClaimFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimFeed, arg.LeaseSeconds, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PublisherIntervalSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const claimFeedsToFetch = `-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET claimed_until = NOW() + ($1::int * INTERVAL '1 second')
+WHERE id IN (
+    SELECT id
+    FROM feeds
+    WHERE disabled_at IS NULL
+    AND (claimed_until IS NULL OR claimed_until < NOW())
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    AND NOT EXISTS (
+        SELECT 1
+        FROM host_backoffs
+        WHERE host_backoffs.host = lower(substring(feeds.url from '://([^/:?#]+)'))
+        AND host_backoffs.retry_after > NOW()
+    )
+    ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
+`
+
+type ClaimFeedsToFetchParams struct {
+	LeaseSeconds int32
+	BatchSize    int32
+}
+
+func (q *Queries) ClaimFeedsToFetch(ctx context.Context, arg ClaimFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimFeedsToFetch, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.ClaimedUntil,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.PublisherIntervalSeconds,
+			&i.SkipHours,
+			&i.SkipDays,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds(id, created_at, updated_at, name, url, user_id)
-VALUES($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+INSERT INTO feeds(id, created_at, updated_at, name, url, user_id, site_url, description, language, image_url)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Url         string
+	UserID      uuid.UUID
+	SiteUrl     string
+	Description string
+	Language    string
+	ImageUrl    string
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -35,7 +156,52 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.SiteUrl,
+		arg.Description,
+		arg.Language,
+		arg.ImageUrl,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PublisherIntervalSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
 	)
+	return i, err
+}
+
+const enableFeed = `-- name: EnableFeed :one
+UPDATE feeds
+SET disabled_at = NULL,
+consecutive_failures = 0,
+last_error = NULL,
+next_fetch_at = NULL,
+updated_at = NOW()
+WHERE url = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, enableFeed, url)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -45,12 +211,27 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PublisherIntervalSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
 	)
 	return i, err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
 FROM feeds
 WHERE url = $1
 `
@@ -66,12 +247,27 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PublisherIntervalSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at 
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url 
 FROM feeds
 `
 
@@ -92,6 +288,73 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.ClaimedUntil,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.PublisherIntervalSeconds,
+			&i.SkipHours,
+			&i.SkipDays,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsByName = `-- name: GetFeedsByName :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
+FROM feeds
+WHERE name = $1
+`
+
+func (q *Queries) GetFeedsByName(ctx context.Context, name string) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByName, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.ClaimedUntil,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.PublisherIntervalSeconds,
+			&i.SkipHours,
+			&i.SkipDays,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -106,15 +369,152 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at 
+const getFeedsWithErrors = `-- name: GetFeedsWithErrors :many
+SELECT *
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at ASC NULLS LAST, consecutive_failures DESC
+`
+
+func (q *Queries) GetFeedsWithErrors(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsWithErrors)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.ClaimedUntil,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.PublisherIntervalSeconds,
+			&i.SkipHours,
+			&i.SkipDays,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markFeedGone = `-- name: MarkFeedGone :one
+UPDATE feeds
+SET last_error = $1,
+consecutive_failures = consecutive_failures + 1,
+disabled_at = NOW(),
+updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
+`
+
+type MarkFeedGoneParams struct {
+	LastError sql.NullString
+	ID        uuid.UUID
+}
+
+func (q *Queries) MarkFeedGone(ctx context.Context, arg MarkFeedGoneParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, markFeedGone, arg.LastError, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PublisherIntervalSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const mergeFeedInto = `-- name: MergeFeedInto :exec
+WITH moved_follows AS (
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+    SELECT gen_random_uuid(), NOW(), NOW(), user_id, $1::uuid
+    FROM feed_follows
+    WHERE feed_id = $2
+    ON CONFLICT (user_id, feed_id) DO NOTHING
+), moved_posts AS (
+    UPDATE posts
+    SET feed_id = $1::uuid
+    WHERE feed_id = $2
+    AND guid NOT IN (SELECT guid FROM posts WHERE feed_id = $1::uuid)
+)
+DELETE FROM feeds
+WHERE id = $2
+`
+
+type MergeFeedIntoParams struct {
+	TargetID uuid.UUID
+	SourceID uuid.UUID
+}
+
+func (q *Queries) MergeFeedInto(ctx context.Context, arg MergeFeedIntoParams) error {
+	_, err := q.db.ExecContext(ctx, mergeFeedInto, arg.TargetID, arg.SourceID)
+	return err
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :one
+UPDATE feeds
+SET last_error = $1,
+consecutive_failures = consecutive_failures + 1,
+disabled_at = CASE
+    WHEN consecutive_failures + 1 >= $2::int THEN NOW()
+    ELSE disabled_at
+END,
+updated_at = NOW()
+WHERE id = $3
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+type RecordFeedFailureParams struct {
+	LastError    sql.NullString
+	DisableAfter int32
+	ID           uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedFailure, arg.LastError, arg.DisableAfter, arg.ID)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -124,20 +524,116 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PublisherIntervalSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
 	)
 	return i, err
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :one
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
 UPDATE feeds
 SET last_fetched_at = NOW(),
+last_error = NULL,
+consecutive_failures = 0,
 updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, markFeedFetched, id)
+func (q *Queries) RecordFeedSuccess(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, id)
+	return err
+}
+
+const releaseFeedClaim = `-- name: ReleaseFeedClaim :exec
+UPDATE feeds
+SET claimed_until = NULL
+WHERE id = $1
+AND claimed_until = $2
+`
+
+type ReleaseFeedClaimParams struct {
+	ID           uuid.UUID
+	ClaimedUntil sql.NullTime
+}
+
+func (q *Queries) ReleaseFeedClaim(ctx context.Context, arg ReleaseFeedClaimParams) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedClaim, arg.ID, arg.ClaimedUntil)
+	return err
+}
+
+const renewFeedClaim = `-- name: RenewFeedClaim :one
+UPDATE feeds
+SET claimed_until = NOW() + ($1::int * INTERVAL '1 second')
+WHERE id = $2
+AND claimed_until = $3
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
+`
+
+type RenewFeedClaimParams struct {
+	LeaseSeconds int32
+	ID           uuid.UUID
+	ClaimedUntil sql.NullTime
+}
+
+func (q *Queries) RenewFeedClaim(ctx context.Context, arg RenewFeedClaimParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, renewFeedClaim, arg.LeaseSeconds, arg.ID, arg.ClaimedUntil)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PublisherIntervalSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const setFeedFetchInterval = `-- name: SetFeedFetchInterval :one
+UPDATE feeds
+SET fetch_interval_seconds = $2,
+next_fetch_at = NULL,
+updated_at = NOW()
+WHERE url = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, claimed_until, next_fetch_at, fetch_interval_seconds, publisher_interval_seconds, skip_hours, skip_days, last_error, consecutive_failures, disabled_at, site_url, description, language, image_url
+`
+
+type SetFeedFetchIntervalParams struct {
+	Url                  string
+	FetchIntervalSeconds sql.NullInt32
+}
+
+func (q *Queries) SetFeedFetchInterval(ctx context.Context, arg SetFeedFetchIntervalParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedFetchInterval, arg.Url, arg.FetchIntervalSeconds)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -147,6 +643,127 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.ClaimedUntil,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.PublisherIntervalSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
 	)
 	return i, err
 }
+
+const setFeedNextFetchAt = `-- name: SetFeedNextFetchAt :exec
+UPDATE feeds
+SET next_fetch_at = $2
+WHERE id = $1
+`
+
+type SetFeedNextFetchAtParams struct {
+	ID          uuid.UUID
+	NextFetchAt sql.NullTime
+}
+
+func (q *Queries) SetFeedNextFetchAt(ctx context.Context, arg SetFeedNextFetchAtParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetchAt, arg.ID, arg.NextFetchAt)
+	return err
+}
+
+const updateFeedCacheValidators = `-- name: UpdateFeedCacheValidators :exec
+UPDATE feeds
+SET etag = $2,
+last_modified = $3,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedCacheValidatorsParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheValidators(ctx context.Context, arg UpdateFeedCacheValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheValidators, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET site_url = $2,
+description = $3,
+language = $4,
+image_url = $5,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID          uuid.UUID
+	SiteUrl     string
+	Description string
+	Language    string
+	ImageUrl    string
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.SiteUrl,
+		arg.Description,
+		arg.Language,
+		arg.ImageUrl,
+	)
+	return err
+}
+
+const updateFeedPollingHints = `-- name: UpdateFeedPollingHints :exec
+UPDATE feeds
+SET publisher_interval_seconds = $2,
+skip_hours = $3,
+skip_days = $4
+WHERE id = $1
+`
+
+type UpdateFeedPollingHintsParams struct {
+	ID                       uuid.UUID
+	PublisherIntervalSeconds int32
+	SkipHours                int32
+	SkipDays                 int32
+}
+
+func (q *Queries) UpdateFeedPollingHints(ctx context.Context, arg UpdateFeedPollingHintsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedPollingHints,
+		arg.ID,
+		arg.PublisherIntervalSeconds,
+		arg.SkipHours,
+		arg.SkipDays,
+	)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedURLParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL, arg.ID, arg.Url)
+	return err
+}
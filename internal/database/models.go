@@ -12,13 +12,40 @@ import (
 )
 
 type Feed struct {
+	ID                       uuid.UUID
+	CreatedAt                time.Time
+	UpdatedAt                time.Time
+	Name                     string
+	Url                      string
+	UserID                   uuid.UUID
+	LastFetchedAt            sql.NullTime
+	Etag                     sql.NullString
+	LastModified             sql.NullString
+	ClaimedUntil             sql.NullTime
+	NextFetchAt              sql.NullTime
+	FetchIntervalSeconds     sql.NullInt32
+	PublisherIntervalSeconds int32
+	SkipHours                int32
+	SkipDays                 int32
+	LastError                sql.NullString
+	ConsecutiveFailures      int32
+	DisabledAt               sql.NullTime
+	SiteUrl                  string
+	Description              string
+	Language                 string
+	ImageUrl                 string
+}
+
+type FeedFetch struct {
 	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	FeedID        uuid.UUID
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	HttpStatus    sql.NullInt32
+	Bytes         int64
+	ItemsSeen     int32
+	ItemsInserted int32
+	Error         sql.NullString
 }
 
 type FeedFollow struct {
@@ -29,15 +56,44 @@ type FeedFollow struct {
 	FeedID    uuid.UUID
 }
 
+type HostBackoff struct {
+	Host       string
+	RetryAfter time.Time
+	UpdatedAt  time.Time
+}
+
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Title               string
+	Url                 string
+	Description         sql.NullString
+	PublishedAt         sql.NullTime
+	FeedID              uuid.UUID
+	PublishedAtInferred bool
+	Guid                string
+	ContentHash         string
+	RevisedAt           sql.NullTime
+	Content             string
+	Author              string
+	OriginalUrl         string
+}
+
+type PostCategory struct {
+	PostID uuid.UUID
+	Name   string
+}
+
+type PostEnclosure struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	PostID          uuid.UUID
+	Url             string
+	MimeType        string
+	Length          int64
+	DurationSeconds int32
+	Episode         sql.NullInt32
 }
 
 type User struct {
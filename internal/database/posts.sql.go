@@ -13,55 +13,24 @@ import (
 	"github.com/google/uuid"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES (
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
-)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const countRecentPostsForFeed = `-- name: CountRecentPostsForFeed :one
+SELECT COUNT(*)
+FROM posts
+WHERE feed_id = $1
+AND published_at_inferred = false
+AND published_at > $2
 `
 
-type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
+type CountRecentPostsForFeedParams struct {
 	FeedID      uuid.UUID
+	PublishedAt sql.NullTime
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
-	)
-	var i Post
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Title,
-		&i.Url,
-		&i.Description,
-		&i.PublishedAt,
-		&i.FeedID,
-	)
-	return i, err
+func (q *Queries) CountRecentPostsForFeed(ctx context.Context, arg CountRecentPostsForFeedParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRecentPostsForFeed, arg.FeedID, arg.PublishedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
 const deletePostById = `-- name: DeletePostById :exec
@@ -73,8 +42,26 @@ func (q *Queries) DeletePostById(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getPostIDByGuid = `-- name: GetPostIDByGuid :one
+SELECT id
+FROM posts
+WHERE feed_id = $1 AND guid = $2
+`
+
+type GetPostIDByGuidParams struct {
+	FeedID uuid.UUID
+	Guid   string
+}
+
+func (q *Queries) GetPostIDByGuid(ctx context.Context, arg GetPostIDByGuidParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getPostIDByGuid, arg.FeedID, arg.Guid)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getPostById = `-- name: GetPostById :one
-SELECT id, created_at, updated_at, title, url, description, published_at, feed_id 
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, published_at_inferred, guid, content_hash, revised_at, content, author, original_url 
 FROM posts
 WHERE id = $1
 `
@@ -91,12 +78,19 @@ func (q *Queries) GetPostById(ctx context.Context, id uuid.UUID) (Post, error) {
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.PublishedAtInferred,
+		&i.Guid,
+		&i.ContentHash,
+		&i.RevisedAt,
+		&i.Content,
+		&i.Author,
+		&i.OriginalUrl,
 	)
 	return i, err
 }
 
 const getPosts = `-- name: GetPosts :many
-SELECT id, created_at, updated_at, title, url, description, published_at, feed_id 
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, published_at_inferred, guid, content_hash, revised_at, content, author, original_url 
 FROM posts ORDER BY published_at ASC LIMIT $1
 `
 
@@ -118,6 +112,13 @@ func (q *Queries) GetPosts(ctx context.Context, limit int32) ([]Post, error) {
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.PublishedAtInferred,
+			&i.Guid,
+			&i.ContentHash,
+			&i.RevisedAt,
+			&i.Content,
+			&i.Author,
+			&i.OriginalUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -133,33 +134,62 @@ func (q *Queries) GetPosts(ctx context.Context, limit int32) ([]Post, error) {
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.published_at_inferred, posts.guid, posts.content_hash, posts.revised_at, posts.content, posts.author, posts.original_url, feeds.name AS feed_name FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON posts.feed_id = feeds.id
 WHERE feed_follows.user_id = $1
+AND (NOT $2::bool OR EXISTS (
+    SELECT 1
+    FROM post_enclosures
+    WHERE post_enclosures.post_id = posts.id
+    AND (post_enclosures.mime_type LIKE 'audio/%' OR post_enclosures.mime_type LIKE 'video/%')
+))
+AND ($3::text = '' OR posts.author ILIKE '%' || $3::text || '%')
+AND ($4::text = '' OR EXISTS (
+    SELECT 1
+    FROM post_categories
+    WHERE post_categories.post_id = posts.id
+    AND lower(post_categories.name) = lower($4::text)
+))
 ORDER BY posts.published_at DESC
-LIMIT $2
+LIMIT $5
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID       uuid.UUID
+	PodcastsOnly bool
+	Author       string
+	Category     string
+	Limit        int32
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Title               string
+	Url                 string
+	Description         sql.NullString
+	PublishedAt         sql.NullTime
+	FeedID              uuid.UUID
+	PublishedAtInferred bool
+	Guid                string
+	ContentHash         string
+	RevisedAt           sql.NullTime
+	Content             string
+	Author              string
+	OriginalUrl         string
+	FeedName            string
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.PodcastsOnly,
+		arg.Author,
+		arg.Category,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +206,13 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.PublishedAtInferred,
+			&i.Guid,
+			&i.ContentHash,
+			&i.RevisedAt,
+			&i.Content,
+			&i.Author,
+			&i.OriginalUrl,
 			&i.FeedName,
 		); err != nil {
 			return nil, err
@@ -191,6 +228,29 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	return items, nil
 }
 
+const rekeyPost = `-- name: RekeyPost :exec
+UPDATE posts
+SET guid = $1
+WHERE feed_id = $2
+AND guid = $3
+AND NOT EXISTS (
+    SELECT 1 FROM posts AS rekeyed
+    WHERE rekeyed.feed_id = $2
+    AND rekeyed.guid = $1
+)
+`
+
+type RekeyPostParams struct {
+	NewGuid string
+	FeedID  uuid.UUID
+	OldGuid string
+}
+
+func (q *Queries) RekeyPost(ctx context.Context, arg RekeyPostParams) error {
+	_, err := q.db.ExecContext(ctx, rekeyPost, arg.NewGuid, arg.FeedID, arg.OldGuid)
+	return err
+}
+
 const resetPosts = `-- name: ResetPosts :exec
 DELETE FROM posts *
 `
@@ -199,3 +259,106 @@ func (q *Queries) ResetPosts(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetPosts)
 	return err
 }
+
+const updatePostAuthor = `-- name: UpdatePostAuthor :exec
+UPDATE posts
+SET author = $2
+WHERE id = $1 AND author <> $2
+`
+
+type UpdatePostAuthorParams struct {
+	ID     uuid.UUID
+	Author string
+}
+
+func (q *Queries) UpdatePostAuthor(ctx context.Context, arg UpdatePostAuthorParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostAuthor, arg.ID, arg.Author)
+	return err
+}
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, published_at_inferred, guid, content_hash, content, author, original_url)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14
+)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    author = EXCLUDED.author,
+    url = EXCLUDED.url,
+    original_url = EXCLUDED.original_url,
+    content_hash = EXCLUDED.content_hash,
+    updated_at = EXCLUDED.updated_at,
+    revised_at = CASE WHEN posts.content_hash = '' OR (posts.content = '' AND EXCLUDED.content <> '') THEN posts.revised_at ELSE EXCLUDED.updated_at END
+WHERE posts.content_hash <> EXCLUDED.content_hash
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, published_at_inferred, guid, content_hash, revised_at, content, author, original_url
+`
+
+type UpsertPostParams struct {
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Title               string
+	Url                 string
+	Description         sql.NullString
+	PublishedAt         sql.NullTime
+	FeedID              uuid.UUID
+	PublishedAtInferred bool
+	Guid                string
+	ContentHash         string
+	Content             string
+	Author              string
+	OriginalUrl         string
+}
+
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.PublishedAtInferred,
+		arg.Guid,
+		arg.ContentHash,
+		arg.Content,
+		arg.Author,
+		arg.OriginalUrl,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.PublishedAtInferred,
+		&i.Guid,
+		&i.ContentHash,
+		&i.RevisedAt,
+		&i.Content,
+		&i.Author,
+		&i.OriginalUrl,
+	)
+	return i, err
+}
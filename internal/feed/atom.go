@@ -0,0 +1,160 @@
+package feed
+
+import (
+	"html"
+	"strings"
+)
+
+type atomFeed struct {
+	Base     string              `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Lang     string              `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Title    atomText            `xml:"title"`
+	Authors  []atomPerson        `xml:"author"`
+	Subtitle atomText            `xml:"subtitle"`
+	Logo     string              `xml:"logo"`
+	Icon     string              `xml:"icon"`
+	Links    []atomLink          `xml:"link"`
+	Entries  itemSink[atomEntry] `xml:"entry"`
+}
+
+type atomEntry struct {
+	Base       string         `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	ID         string         `xml:"id"`
+	Title      atomText       `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    atomText       `xml:"summary"`
+	Content    atomText       `xml:"content"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+// atomCategory is an Atom category. The term identifies it, the optional
+// label is meant for display.
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type atomLink struct {
+	Base   string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// atomText is an Atom text construct. Plain text and escaped html arrive as
+// character data, xhtml arrives as inline markup inside a div.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// Plain returns the text for fields shown as plain text, such as titles:
+// entities in html text are decoded, other text is taken as it is.
+func (t atomText) Plain() string {
+	if t.Type == "html" {
+		return html.UnescapeString(t.String())
+	}
+	return t.String()
+}
+
+// alternateLink returns the rel="alternate" link, which is also the meaning
+// of a link without a rel attribute.
+func alternateLink(links []atomLink) atomLink {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link
+		}
+	}
+	return atomLink{}
+}
+
+// enclosureLinks returns the rel="enclosure" links as RSS enclosures.
+func enclosureLinks(links []atomLink) []rssEnclosure {
+	var enclosures []rssEnclosure
+	for _, link := range links {
+		if link.Rel == "enclosure" {
+			enclosures = append(enclosures, rssEnclosure{URL: link.Href, Type: link.Type, Length: link.Length})
+		}
+	}
+	return enclosures
+}
+
+// toRSS normalizes the feed level elements of the Atom document into the
+// RSSFeed model that the rest of the app stores. Entries are converted one by
+// one by item as they are decoded.
+func (f *atomFeed) toRSS() *RSSFeed {
+	feed := RSSFeed{Base: f.Base}
+	feed.Channel.Title = f.Title.Plain()
+	feed.Channel.Link = alternateLink(f.Links).Href
+	feed.Channel.Description = f.Subtitle.Plain()
+	feed.Channel.Language = f.Lang
+	feed.Channel.Image.URL = f.Logo
+	if feed.Channel.Image.URL == "" {
+		feed.Channel.Image.URL = f.Icon
+	}
+	return &feed
+}
+
+// item converts an entry. Feed level authors are used as far as they have
+// been read, which in practice is always, since they precede the entries.
+func (f *atomFeed) item(entry atomEntry) RSSItem {
+	description := entry.Summary.String()
+	if description == "" {
+		description = entry.Content.String()
+	}
+
+	published := entry.Published
+	if published == "" {
+		published = entry.Updated
+	}
+
+	// An entry without authors inherits those of the feed.
+	authors := entry.Authors
+	if len(authors) == 0 {
+		authors = f.Authors
+	}
+	var creator string
+	if len(authors) > 0 {
+		creator = strings.TrimSpace(authors[0].Name)
+	}
+
+	var categories []string
+	for _, category := range entry.Categories {
+		if category.Label != "" {
+			categories = append(categories, category.Label)
+		} else {
+			categories = append(categories, category.Term)
+		}
+	}
+
+	link := alternateLink(entry.Links)
+	return RSSItem{
+		Base:        entry.Base,
+		Title:       entry.Title.Plain(),
+		Link:        link.Href,
+		linkBase:    link.Base,
+		Description: description,
+		Content:     entry.Content.String(),
+		PubDate:     published,
+		Creator:     creator,
+		Categories:  categories,
+		GUID:        strings.TrimSpace(entry.ID),
+		Enclosures:  enclosureLinks(entry.Links),
+	}
+}
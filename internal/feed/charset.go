@@ -0,0 +1,122 @@
+package feed
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+)
+
+// windows1252 maps each windows-1252 byte to its rune. It only differs from
+// ISO-8859-1 in 0x80-0x9F, where ISO-8859-1 has unused control codes.
+var windows1252 = func() (table [256]rune) {
+	for i := range table {
+		table[i] = rune(i)
+	}
+	// 0x81, 0x8D, 0x8F, 0x90 and 0x9D are unassigned and map to themselves.
+	high := map[byte]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+		0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+		0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+		0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+	}
+	for b, r := range high {
+		table[b] = r
+	}
+	return table
+}()
+
+// charsetReader converts input in the named charset to UTF-8. It has the
+// signature xml.Decoder.CharsetReader expects.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	if isUTF8(label) {
+		return input, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	// Like browsers, ISO-8859-1 is read as windows-1252: feeds labelled
+	// Latin-1 are usually windows-1252 in practice, and the two only differ
+	// in control codes that never appear in text.
+	case "iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "l1", "cp819",
+		"windows-1252", "cp1252", "x-cp1252":
+		return &singleByteReader{r: bufio.NewReader(input), table: &windows1252}, nil
+	default:
+		return nil, fmt.Errorf("unsupported charset %q", label)
+	}
+}
+
+// newXMLDecoder returns a decoder for the document. A charset in the
+// Content-Type takes precedence over the encoding declared in the XML
+// prolog, except that a UTF-8 charset is ignored: servers add it by default
+// to documents whose prolog knows better.
+func newXMLDecoder(contentType string, body io.Reader) (*xml.Decoder, error) {
+	_, params, _ := mime.ParseMediaType(contentType)
+	label := params["charset"]
+	if isUTF8(label) {
+		decoder := xml.NewDecoder(body)
+		decoder.CharsetReader = charsetReader
+		return decoder, nil
+	}
+
+	converted, err := charsetReader(label, body)
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(converted)
+	// The text is UTF-8 by now whatever the prolog claims.
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder, nil
+}
+
+// isUTF8 reports whether the charset label is empty or names UTF-8 or its
+// ASCII subset.
+func isUTF8(label string) bool {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
+
+// singleByteReader decodes a single-byte charset to UTF-8 using a table.
+type singleByteReader struct {
+	r       *bufio.Reader
+	table   *[256]rune
+	pending []byte
+	scratch [utf8.UTFMax]byte
+}
+
+func (s *singleByteReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(s.pending) > 0 {
+			copied := copy(p[n:], s.pending)
+			s.pending = s.pending[copied:]
+			n += copied
+			continue
+		}
+		// Hand back what is decoded rather than wait on the network.
+		if n > 0 && s.r.Buffered() == 0 {
+			break
+		}
+
+		b, err := s.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if r := s.table[b]; r < utf8.RuneSelf {
+			p[n] = byte(r)
+			n++
+		} else {
+			s.pending = utf8.AppendRune(s.scratch[:0], r)
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,112 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCharsetReader(t *testing.T) {
+	// "Café – “quoted” €5…" in windows-1252.
+	const latin = "Caf\xe9 \x96 \x93quoted\x94 \x805\x85"
+	const want = "Café – “quoted” €5…"
+
+	tests := []struct {
+		label string
+		input string
+		want  string
+	}{
+		{"", "plain ascii", "plain ascii"},
+		{"UTF-8", "déjà vu", "déjà vu"},
+		{"us-ascii", "plain ascii", "plain ascii"},
+		{"windows-1252", latin, want},
+		{"CP1252", latin, want},
+		{" ISO-8859-1 ", latin, want},
+		{"latin1", latin, want},
+		{"windows-1252", "\x81\x8d\x8f\x90\x9d", "\u0081\u008d\u008f\u0090\u009d"},
+		{"windows-1252", "\xa0\xff", " ÿ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			reader, err := charsetReader(tt.label, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("charsetReader(%q): %v", tt.label, err)
+			}
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decoded %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCharsetReaderUnsupported(t *testing.T) {
+	if _, err := charsetReader("shift_jis", strings.NewReader("")); err == nil {
+		t.Error("charsetReader(shift_jis) succeeded, want an error")
+	}
+}
+
+func TestSingleByteReaderSmallReads(t *testing.T) {
+	// Every rune here is several bytes in UTF-8, so reads of one byte have to
+	// hand each over in pieces.
+	reader := &singleByteReader{r: bufio.NewReader(iotest.OneByteReader(strings.NewReader("\x80\x93\xe9"))), table: &windows1252}
+	var got bytes.Buffer
+	buf := make([]byte, 1)
+	for {
+		n, err := reader.Read(buf)
+		got.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got.String() != "€“é" {
+		t.Errorf("decoded %q, want %q", got.String(), "€“é")
+	}
+}
+
+func TestParseFeedCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name: "declared in the prolog",
+			body: "<?xml version=\"1.0\" encoding=\"windows-1252\"?><rss><channel><item><title>Caf\xe9 \x93\x80\x94</title></item></channel></rss>",
+		},
+		{
+			name:        "declared in the content type",
+			contentType: "application/rss+xml; charset=ISO-8859-1",
+			body:        "<rss><channel><item><title>Caf\xe9 \x93\x80\x94</title></item></channel></rss>",
+		},
+		{
+			name:        "content type wins over the prolog",
+			contentType: "text/xml; charset=windows-1252",
+			body:        "<?xml version=\"1.0\" encoding=\"utf-8\"?><rss><channel><item><title>Caf\xe9 \x93\x80\x94</title></item></channel></rss>",
+		},
+		{
+			name:        "utf-8 content type defers to the prolog",
+			contentType: "application/xml; charset=utf-8",
+			body:        "<?xml version=\"1.0\" encoding=\"windows-1252\"?><rss><channel><item><title>Caf\xe9 \x93\x80\x94</title></item></channel></rss>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, items := parseItems(t, tt.contentType, tt.body)
+			if len(items) != 1 || items[0].Title != "Café “€”" {
+				t.Errorf("items = %+v, want one titled %q", items, "Café “€”")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package feed
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dateLayouts is the catalog of publish date layouts seen in real feeds. They
+// are tried in order against the value after the weekday has been stripped and
+// any zone abbreviation has been replaced by its numeric offset.
+var dateLayouts = []string{
+	// RFC 822 / RFC 1123 and the common ways feeds get them wrong.
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 06 15:04:05",
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04 -0700",
+	"2 January 2006 15:04:05",
+	"2 Jan 2006",
+	"2-Jan-06 15:04:05 -0700",
+	"2-Jan-2006 15:04:05 -0700",
+	"2 Jan 2006 3:04:05 PM -0700",
+	"2 Jan 2006 3:04 PM -0700",
+	"Jan 2, 2006 15:04:05 -0700",
+	"Jan 2, 2006 3:04 PM -0700",
+	"Jan 2, 2006",
+	"January 2, 2006 15:04:05 -0700",
+	"January 2, 2006",
+
+	// ISO 8601 as used by Atom, JSON Feed and Dublin Core.
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+
+	// C library formats, which keep the weekday without a comma.
+	time.UnixDate,
+	time.RubyDate,
+	time.ANSIC,
+}
+
+// zoneOffsets maps the zone abbreviations found in feeds to numeric offsets.
+// time.Parse only knows the local zone's abbreviation and silently treats any
+// other as UTC.
+var zoneOffsets = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"WET":  "+0000",
+	"WEST": "+0100",
+	"BST":  "+0100",
+	"CET":  "+0100",
+	"MET":  "+0100",
+	"CEST": "+0200",
+	"MEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"IST":  "+0530",
+	"SGT":  "+0800",
+	"HKT":  "+0800",
+	"AWST": "+0800",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"ACST": "+0930",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+	"NST":  "-0330",
+	"NDT":  "-0230",
+	"AST":  "-0400",
+	"ADT":  "-0300",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+}
+
+var (
+	// leadingWeekday matches a weekday in any language followed by a comma,
+	// e.g. "Tue," or "Mié.,". The weekday is redundant so it is dropped rather
+	// than translated.
+	leadingWeekday = regexp.MustCompile(`^\pL+\.?,\s*`)
+	// bareWeekday matches an English weekday followed directly by the day of
+	// the month, e.g. "Tue 10 Jun". Without the comma only known names are
+	// dropped, so a leading month such as "Jan 2, 2006" is kept.
+	bareWeekday  = regexp.MustCompile(`(?i)^(?:mon|tue|wed|thu|fri|sat|sun)[a-z]*\.?\s+(\d)`)
+	parenComment = regexp.MustCompile(`\s*\([^)]*\)\s*$`)
+)
+
+// ParseDate parses a feed publish date written in any of the layouts in
+// dateLayouts. It reports false when none of them match.
+func ParseDate(value string) (time.Time, bool) {
+	value = normalizeDate(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// NormalizeDate parses a feed publish date, falling back to fetchedAt when
+// the date is missing or unparseable. inferred reports whether the fallback
+// was used.
+func NormalizeDate(value string, fetchedAt time.Time) (t time.Time, inferred bool) {
+	if t, ok := ParseDate(value); ok {
+		return t, false
+	}
+	return fetchedAt.UTC(), true
+}
+
+func normalizeDate(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	value = parenComment.ReplaceAllString(value, "")
+	value = leadingWeekday.ReplaceAllString(value, "")
+	value = bareWeekday.ReplaceAllString(value, "$1")
+
+	fields := strings.Fields(value)
+	if len(fields) > 1 {
+		last := fields[len(fields)-1]
+		if offset, ok := zoneOffsets[strings.ToUpper(last)]; ok {
+			fields[len(fields)-1] = offset
+			value = strings.Join(fields, " ")
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,139 @@
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		// RFC 822 / RFC 1123 and variants.
+		{"Tue, 10 Jun 2003 04:00:00 +0200", "2003-06-10T02:00:00Z"},
+		{"Tue, 10 Jun 2003 04:00:00 +02:00", "2003-06-10T02:00:00Z"},
+		{"Tue, 10 Jun 2003 04:00 +0200", "2003-06-10T02:00:00Z"},
+		{"Tue, 10 Jun 2003 04:00:00", "2003-06-10T04:00:00Z"},
+		{"Tue, 10 Jun 2003 04:00", "2003-06-10T04:00:00Z"},
+		{"Tue, 10 Jun 03 04:00:00 +0200", "2003-06-10T02:00:00Z"},
+		{"Tue, 10 Jun 03 04:00 +0200", "2003-06-10T02:00:00Z"},
+		{"Tue, 10 Jun 03 04:00:00", "2003-06-10T04:00:00Z"},
+		{"Tuesday, 10 June 2003 04:00:00 +0200", "2003-06-10T02:00:00Z"},
+		{"10 June 2003 04:00 +0200", "2003-06-10T02:00:00Z"},
+		{"10 June 2003 04:00:00", "2003-06-10T04:00:00Z"},
+		{"10 Jun 2003", "2003-06-10T00:00:00Z"},
+		{"10-Jun-03 04:00:00 +0000", "2003-06-10T04:00:00Z"},
+		{"10-Jun-2003 04:00:00 +0000", "2003-06-10T04:00:00Z"},
+		{"Sat, 10 Jun 2023 4:00:30 PM EDT", "2023-06-10T20:00:30Z"},
+		{"Sat, 10 Jun 2023 4:00 PM EDT", "2023-06-10T20:00:00Z"},
+		{"Jun 10, 2003 04:00:00 +0000", "2003-06-10T04:00:00Z"},
+		{"Jun 10, 2003 4:00 PM +0000", "2003-06-10T16:00:00Z"},
+		{"Jun 10, 2003", "2003-06-10T00:00:00Z"},
+		{"June 10, 2003 04:00:00 +0000", "2003-06-10T04:00:00Z"},
+		{"June 10, 2003", "2003-06-10T00:00:00Z"},
+
+		// Weekdays without a comma, in other languages and in comments.
+		{"Tue 10 Jun 2003 04:00:00 GMT", "2003-06-10T04:00:00Z"},
+		{"tuesday 10 Jun 2003 04:00:00 GMT", "2003-06-10T04:00:00Z"},
+		{"Mié., 10 Jun 2003 04:00:00 +0000", "2003-06-10T04:00:00Z"},
+		{"Tue, 10 Jun 2003 04:00:00 +0000 (UTC)", "2003-06-10T04:00:00Z"},
+		{"  Tue,  10 Jun 2003\n04:00:00 GMT ", "2003-06-10T04:00:00Z"},
+
+		// ISO 8601.
+		{"2003-06-10T04:00:00.123Z", "2003-06-10T04:00:00.123Z"},
+		{"2003-06-10T04:00:00+02:00", "2003-06-10T02:00:00Z"},
+		{"2003-06-10T04:00+02:00", "2003-06-10T02:00:00Z"},
+		{"2003-06-10T04:00:00+0200", "2003-06-10T02:00:00Z"},
+		{"2003-06-10T04:00:00", "2003-06-10T04:00:00Z"},
+		{"2003-06-10 04:00:00Z", "2003-06-10T04:00:00Z"},
+		{"2003-06-10 04:00:00 +0200", "2003-06-10T02:00:00Z"},
+		{"2003-06-10 04:00:00", "2003-06-10T04:00:00Z"},
+		{"2003-06-10 04:00", "2003-06-10T04:00:00Z"},
+		{"2003-06-10", "2003-06-10T00:00:00Z"},
+
+		// C library formats.
+		{"Tue Jun 10 04:00:00 UTC 2003", "2003-06-10T04:00:00Z"},
+		{"Tue Jun 10 04:00:00 +0200 2003", "2003-06-10T02:00:00Z"},
+		{"Tue Jun 10 04:00:00 2003", "2003-06-10T04:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParseDate(tt.value)
+			if !ok {
+				t.Fatalf("ParseDate(%q) failed", tt.value)
+			}
+			want, err := time.Parse(time.RFC3339Nano, tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("ParseDate(%q) = %s, want %s", tt.value, got, want)
+			}
+			if got.Location() != time.UTC {
+				t.Errorf("ParseDate(%q) is in %s, want UTC", tt.value, got.Location())
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, value := range []string{"", "   ", "yesterday", "Tue, 32 Jun 2003 04:00:00 GMT", "10/06/2003"} {
+		if got, ok := ParseDate(value); ok {
+			t.Errorf("ParseDate(%q) = %s, want failure", value, got)
+		}
+	}
+}
+
+func TestParseDateZoneAbbreviations(t *testing.T) {
+	for abbreviation, offset := range zoneOffsets {
+		t.Run(abbreviation, func(t *testing.T) {
+			got, ok := ParseDate("Tue, 10 Jun 2003 12:00:00 " + abbreviation)
+			if !ok {
+				t.Fatalf("zone %s not parsed", abbreviation)
+			}
+			want, err := time.Parse("2006-01-02 15:04:05 -0700", "2003-06-10 12:00:00 "+offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("zone %s: got %s, want %s", abbreviation, got, want)
+			}
+		})
+	}
+}
+
+func TestNormalizeDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Tue, 10 Jun 2003 04:00:00 GMT", "10 Jun 2003 04:00:00 +0000"},
+		{"Tue 10 Jun 2003 04:00:00 pdt", "10 Jun 2003 04:00:00 -0700"},
+		{"Jan 2, 2006", "Jan 2, 2006"},
+		{"Tue Jun 10 04:00:00 2003", "Tue Jun 10 04:00:00 2003"},
+		{"10 Jun 2003 04:00:00 +0000 (Coordinated Universal Time)", "10 Jun 2003 04:00:00 +0000"},
+		{"EST", "EST"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeDate(tt.value); got != tt.want {
+			t.Errorf("normalizeDate(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDateFallback(t *testing.T) {
+	fetchedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600))
+
+	got, inferred := NormalizeDate("not a date", fetchedAt)
+	if !inferred || !got.Equal(fetchedAt) || got.Location() != time.UTC {
+		t.Errorf("NormalizeDate fallback = %s, %v; want %s, true", got, inferred, fetchedAt.UTC())
+	}
+
+	got, inferred = NormalizeDate("2003-06-10T04:00:00Z", fetchedAt)
+	if inferred || !got.Equal(time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)) {
+		t.Errorf("NormalizeDate = %s, %v; want 2003-06-10 04:00:00 UTC, false", got, inferred)
+	}
+}
@@ -0,0 +1,185 @@
+package feed
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// feedLinkTypes are the <link type> values that announce a feed.
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+}
+
+// commonFeedPaths are tried on the site when a page does not link its feed.
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss",
+	"/feed.xml",
+	"/rss.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+var (
+	linkTag      = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	tagAttribute = regexp.MustCompile(`(?s)([\w:-]+)\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+)`)
+)
+
+// Discover finds the feeds behind the requested URL. A URL that serves a feed
+// is returned as is. For a web page the feeds it links with
+// <link rel="alternate"> are returned in page order, or failing that the
+// first of commonFeedPaths on the same site that serves a feed. No feed found
+// is not an error.
+//
+// Every download honours the request's MaxBytes and TrackingParams. When the
+// feed itself was downloaded along the way, because the URL served it or it
+// was found at a common path, its FetchResult is returned too so the caller
+// need not fetch it again.
+func Discover(ctx context.Context, fetchReq FetchRequest) ([]string, *FetchResult, error) {
+	ctx, stall, cancel := withStallTimeout(ctx, fetchReq.URL)
+	defer cancel()
+	httpClient := http.Client{}
+	req, err := http.NewRequestWithContext(ctx, "GET", fetchReq.URL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	setRequestHeaders(req)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, stall.err(err)
+	}
+	defer resp.Body.Close()
+	resp.Body = stall.body(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, statusError(fetchReq.URL, resp)
+	}
+	if resp.ContentLength > fetchReq.maxBytes() {
+		return nil, nil, &TooLargeError{URL: fetchReq.URL, Limit: fetchReq.maxBytes()}
+	}
+
+	decoded, err := decodeContent(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer decoded.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	body := &countingReader{r: http.MaxBytesReader(nil, decoded, fetchReq.maxBytes())}
+	reader := bufio.NewReaderSize(body, sniffLen)
+	head, _ := reader.Peek(sniffLen)
+
+	if !isHTML(contentType, head) {
+		result := &FetchResult{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			StatusCode:   resp.StatusCode,
+			FinalURL:     resp.Request.URL.String(),
+		}
+		err := fetchReq.readFeed(result, contentType, reader)
+		result.Bytes = body.n
+		var tooLarge *TooLargeError
+		if errors.As(err, &tooLarge) {
+			return nil, nil, err
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s is neither a feed nor a web page: %w", fetchReq.URL, err)
+		}
+		return []string{fetchReq.URL}, result, nil
+	}
+
+	page, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, fetchReq.limitError(err)
+	}
+	// Links are relative to where the page ended up after redirects.
+	if links := alternateLinks(resp.Request.URL, page); len(links) > 0 {
+		return links, nil, nil
+	}
+
+	for _, path := range commonFeedPaths {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		candidate := fetchReq
+		candidate.URL = resp.Request.URL.ResolveReference(&url.URL{Path: path}).String()
+		if result, err := Fetch(ctx, candidate); err == nil && result.Feed != nil {
+			return []string{candidate.URL}, result, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// isHTML reports whether the response is a web page rather than a feed, by
+// its content type or, failing that, by sniffing the start of the body.
+func isHTML(contentType string, head []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			return true
+		case "application/rss+xml", "application/atom+xml", "application/rdf+xml",
+			"application/feed+json", "application/json", "application/xml", "text/xml":
+			return false
+		}
+	}
+	return strings.HasPrefix(http.DetectContentType(head), "text/html")
+}
+
+// alternateLinks returns the absolute URLs of the feeds the page announces
+// with <link rel="alternate" type="..."> tags.
+func alternateLinks(base *url.URL, page []byte) []string {
+	var links []string
+	seen := map[string]bool{}
+
+	for _, tag := range linkTag.FindAll(page, -1) {
+		attrs := map[string]string{}
+		for _, match := range tagAttribute.FindAllSubmatch(tag, -1) {
+			value := strings.Trim(string(match[2]), `"'`)
+			attrs[strings.ToLower(string(match[1]))] = html.UnescapeString(value)
+		}
+
+		if !hasToken(attrs["rel"], "alternate") {
+			continue
+		}
+		mediaType, _, _ := mime.ParseMediaType(attrs["type"])
+		if !feedLinkTypes[mediaType] {
+			continue
+		}
+		href, err := base.Parse(strings.TrimSpace(attrs["href"]))
+		if err != nil || attrs["href"] == "" {
+			continue
+		}
+
+		link := href.String()
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// hasToken reports whether the space separated list contains token, ignoring
+// case as HTML does for rel values.
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
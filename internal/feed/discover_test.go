@@ -0,0 +1,91 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const discoverFeed = `<rss><channel><title>Site</title><item><title>one</title><link>/one?utm_source=x</link></item></channel></rss>`
+
+func TestDiscover(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(discoverFeed))
+	})
+	mux.HandleFunc("/linked", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><link rel="alternate" type="application/atom+xml" href="/atom"></head></html>`))
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html>" + strings.Repeat("x", 1000) + "</html>"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>no links</body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		want         string
+		wantFeed     bool
+		wantRequests int
+	}{
+		{name: "feed served directly", path: "/feed.xml", want: "/feed.xml", wantFeed: true, wantRequests: 1},
+		{name: "feed linked from the page", path: "/linked", want: "/atom", wantRequests: 1},
+		// "/feed" and "/rss" are tried before "/feed.xml".
+		{name: "feed at a common path", path: "/", want: "/feed.xml", wantFeed: true, wantRequests: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			links, result, err := Discover(context.Background(), FetchRequest{URL: server.URL + tt.path})
+			if err != nil {
+				t.Fatalf("Discover: %v", err)
+			}
+			if len(links) != 1 || links[0] != server.URL+tt.want {
+				t.Errorf("links = %v, want %s", links, server.URL+tt.want)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", requests, tt.wantRequests)
+			}
+			if !tt.wantFeed {
+				if result != nil {
+					t.Errorf("result = %+v, want none for a feed not downloaded", result)
+				}
+				return
+			}
+			if result == nil || result.Feed == nil {
+				t.Fatal("no feed returned for a feed already downloaded")
+			}
+			items := result.Feed.Channel.Item
+			if len(items) != 1 || items[0].Link != server.URL+"/one" {
+				t.Errorf("items = %+v, want one with its link resolved and canonicalized", items)
+			}
+		})
+	}
+
+	_, _, err := Discover(context.Background(), FetchRequest{URL: server.URL + "/big", MaxBytes: 100})
+	var tooLarge *TooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("Discover over MaxBytes = %v, want a TooLargeError", err)
+	}
+}
@@ -0,0 +1,89 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrGone matches, via errors.Is, a StatusError for 410 Gone: the publisher
+// has removed the feed for good.
+var ErrGone = errors.New("feed is gone")
+
+// StatusError is returned when the server answers with a status that is
+// neither a success nor 304 Not Modified.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == ErrGone && e.StatusCode == http.StatusGone
+}
+
+// TooLargeError is returned when the response body is bigger than the limit
+// the fetch allows. Whatever was parsed of it is discarded.
+type TooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("%s: response larger than %d bytes", e.URL, e.Limit)
+}
+
+// RateLimitError is returned for 429 Too Many Requests and 503 Service
+// Unavailable. RetryAfter is when the server said it may be contacted again,
+// zero when it did not send a usable Retry-After header.
+type RateLimitError struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter.IsZero() {
+		return fmt.Sprintf("%s: rate limited with status %d", e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: rate limited with status %d, retry after %s", e.URL, e.StatusCode, e.RetryAfter.Format(time.RFC1123))
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return time.Time{}, false
+		}
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// statusError maps a non-success response to one of the typed errors above.
+func statusError(feedURL string, resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		err := &RateLimitError{URL: feedURL, StatusCode: resp.StatusCode}
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			err.RetryAfter = retryAfter.UTC()
+		}
+		return err
+	default:
+		return &StatusError{URL: feedURL, StatusCode: resp.StatusCode}
+	}
+}
@@ -0,0 +1,93 @@
+package feed
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "missing", value: ""},
+		{name: "seconds", value: "120", want: now.Add(2 * time.Minute), wantOK: true},
+		{name: "seconds with spaces", value: " 30 ", want: now.Add(30 * time.Second), wantOK: true},
+		{name: "zero seconds", value: "0", want: now, wantOK: true},
+		{name: "more than a day", value: "172800", want: now.Add(48 * time.Hour), wantOK: true},
+		{name: "negative seconds", value: "-5"},
+		{name: "http date", value: "Fri, 01 Mar 2024 12:10:00 GMT", want: now.Add(10 * time.Minute), wantOK: true},
+		{name: "http date in the past", value: "Thu, 29 Feb 2024 12:00:00 GMT", want: now.Add(-24 * time.Hour), wantOK: true},
+		{name: "rfc 850 date", value: "Friday, 01-Mar-24 13:00:00 GMT", want: now.Add(time.Hour), wantOK: true},
+		{name: "fractional seconds", value: "1.5"},
+		{name: "garbage", value: "soon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("parseRetryAfter(%q) = %s, %v; want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		retryAfter    string
+		wantRateLimit bool
+		wantRetry     bool
+		wantGone      bool
+	}{
+		{name: "too many requests", status: http.StatusTooManyRequests, wantRateLimit: true},
+		{name: "too many requests with retry after", status: http.StatusTooManyRequests, retryAfter: "60", wantRateLimit: true, wantRetry: true},
+		{name: "service unavailable", status: http.StatusServiceUnavailable, retryAfter: "Fri, 01 Mar 2024 12:10:00 GMT", wantRateLimit: true, wantRetry: true},
+		{name: "bad retry after ignored", status: http.StatusServiceUnavailable, retryAfter: "later", wantRateLimit: true},
+		{name: "gone", status: http.StatusGone, wantGone: true},
+		{name: "not found", status: http.StatusNotFound},
+		{name: "server error", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+			err := statusError("https://example.com/feed", resp)
+
+			var rateLimited *RateLimitError
+			if errors.As(err, &rateLimited) != tt.wantRateLimit {
+				t.Fatalf("statusError = %#v, rate limited want %v", err, tt.wantRateLimit)
+			}
+			if tt.wantRateLimit {
+				if rateLimited.StatusCode != tt.status {
+					t.Errorf("status = %d, want %d", rateLimited.StatusCode, tt.status)
+				}
+				if rateLimited.RetryAfter.IsZero() == tt.wantRetry {
+					t.Errorf("RetryAfter = %s, want set %v", rateLimited.RetryAfter, tt.wantRetry)
+				}
+				if tt.wantRetry && rateLimited.RetryAfter.Location() != time.UTC {
+					t.Errorf("RetryAfter is in %s, want UTC", rateLimited.RetryAfter.Location())
+				}
+				return
+			}
+
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+				t.Errorf("statusError = %#v, want a StatusError for %d", err, tt.status)
+			}
+			if errors.Is(err, ErrGone) != tt.wantGone {
+				t.Errorf("errors.Is(err, ErrGone) = %v, want %v", !tt.wantGone, tt.wantGone)
+			}
+		})
+	}
+}
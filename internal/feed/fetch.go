@@ -0,0 +1,293 @@
+package feed
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxBytes is the largest response body accepted when a fetch does
+// not set its own limit.
+const DefaultMaxBytes = 10 << 20
+
+// stallTimeout is how long a fetch waits on the server, for the response
+// headers and then for each read of the body. It does not bound the fetch as
+// a whole, which would also count the time OnItem spends on the items.
+var stallTimeout = 10 * time.Second
+
+// FetchRequest describes a feed fetch. ETag and LastModified are the cache
+// validators returned by the previous fetch, if any, and turn the request into
+// a conditional GET. MaxBytes caps the response body, zero meaning
+// DefaultMaxBytes. TrackingParams are the query parameters stripped from item
+// links, nil meaning DefaultTrackingParams.
+type FetchRequest struct {
+	URL            string
+	ETag           string
+	LastModified   string
+	MaxBytes       int64
+	TrackingParams []string
+	// OnItem, when set, is called with each item as soon as it has been
+	// decoded, links resolved, while the rest of the body is still being
+	// read; the items are then not kept in the result's Feed. Time spent in
+	// it does not count towards the fetch timeout, which only bounds waiting
+	// on the server. An error from it aborts the fetch and is returned.
+	OnItem func(RSSItem) error
+}
+
+// FetchResult is the outcome of a fetch. When NotModified is set the server
+// answered 304 and Feed is nil. Feed holds the items only when the request
+// had no OnItem. Once the server has responded, Fetch returns
+// a result carrying StatusCode and Bytes even alongside an error, so callers
+// can log what was received.
+type FetchResult struct {
+	Feed         *RSSFeed
+	NotModified  bool
+	ETag         string
+	LastModified string
+	StatusCode   int
+	Bytes        int64
+	// FinalURL is the URL the feed was served from after any redirects.
+	FinalURL string
+	// PermanentRedirect is set when FinalURL differs from the requested URL
+	// and every redirect on the way was permanent (301 or 308), meaning the
+	// feed should be fetched from FinalURL from now on.
+	PermanentRedirect bool
+}
+
+func Fetch(ctx context.Context, fetchReq FetchRequest) (*FetchResult, error) {
+	redirected, permanent := false, true
+	ctx, stall, cancel := withStallTimeout(ctx, fetchReq.URL)
+	defer cancel()
+	httpClient := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			redirected = true
+			status := req.Response.StatusCode
+			if status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+				permanent = false
+			}
+			return nil
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fetchReq.URL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	setRequestHeaders(req)
+	if fetchReq.ETag != "" {
+		req.Header.Set("If-None-Match", fetchReq.ETag)
+	}
+	if fetchReq.LastModified != "" {
+		req.Header.Set("If-Modified-Since", fetchReq.LastModified)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, stall.err(err)
+	}
+	defer resp.Body.Close()
+	resp.Body = stall.body(resp.Body)
+
+	result := &FetchResult{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StatusCode:   resp.StatusCode,
+		FinalURL:     resp.Request.URL.String(),
+	}
+	result.PermanentRedirect = redirected && permanent && result.FinalURL != fetchReq.URL
+
+	if resp.StatusCode == http.StatusNotModified {
+		// A 304 may omit the validators, in which case the old ones still hold.
+		if result.ETag == "" {
+			result.ETag = fetchReq.ETag
+		}
+		if result.LastModified == "" {
+			result.LastModified = fetchReq.LastModified
+		}
+		result.NotModified = true
+		return result, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, statusError(fetchReq.URL, resp)
+	}
+
+	maxBytes := fetchReq.maxBytes()
+	if resp.ContentLength > maxBytes {
+		return result, &TooLargeError{URL: fetchReq.URL, Limit: maxBytes}
+	}
+
+	decoded, err := decodeContent(resp)
+	if err != nil {
+		return result, err
+	}
+	defer decoded.Close()
+
+	// The body is decoded as it streams in rather than read into memory
+	// first, and reading stops with an error once it passes maxBytes. The
+	// limit applies after decompression so a small gzip cannot expand into
+	// an unbounded document.
+	body := &countingReader{r: http.MaxBytesReader(nil, decoded, maxBytes)}
+	err = fetchReq.readFeed(result, resp.Header.Get("Content-Type"), body)
+	result.Bytes = body.n
+	return result, err
+}
+
+// maxBytes is the cap on the decoded response body.
+func (fetchReq FetchRequest) maxBytes() int64 {
+	if fetchReq.MaxBytes <= 0 {
+		return DefaultMaxBytes
+	}
+	return fetchReq.MaxBytes
+}
+
+// readFeed parses the feed in body into result.Feed, resolving and
+// canonicalizing the links of each item before it is handed to OnItem or
+// kept.
+func (fetchReq FetchRequest) readFeed(result *FetchResult, contentType string, body io.Reader) error {
+	trackingParams := fetchReq.TrackingParams
+	if trackingParams == nil {
+		trackingParams = DefaultTrackingParams
+	}
+	var items []RSSItem
+	handle := func(feed *RSSFeed, item RSSItem) error {
+		item.resolveLinks(feed.channelBase(result.FinalURL))
+		item.canonicalizeLink(trackingParams)
+		if fetchReq.OnItem != nil {
+			return fetchReq.OnItem(item)
+		}
+		items = append(items, item)
+		return nil
+	}
+
+	feed, err := parseFeed(contentType, body, handle)
+	if err != nil {
+		return fetchReq.limitError(err)
+	}
+	feed.resolveLinks(result.FinalURL)
+	feed.Channel.Item = items
+
+	result.Feed = feed
+	return nil
+}
+
+// limitError turns the error of a read that went past maxBytes into a
+// TooLargeError, returning any other error as is.
+func (fetchReq FetchRequest) limitError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return &TooLargeError{URL: fetchReq.URL, Limit: fetchReq.maxBytes()}
+	}
+	return err
+}
+
+// setRequestHeaders sets the headers sent with every request for a feed.
+func setRequestHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+	// Setting this ourselves turns off the transport's transparent gzip, so
+	// decodeContent undoes the encoding. Brotli is not offered since the
+	// standard library cannot decode it.
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+}
+
+// decodeContent undoes the Content-Encoding of the response body.
+func decodeContent(resp *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		// HTTP deflate is meant to be zlib wrapped, but some servers send a
+		// raw deflate stream, recognisable by the missing zlib header.
+		reader := bufio.NewReader(resp.Body)
+		header, err := reader.Peek(2)
+		if err != nil {
+			return nil, err
+		}
+		if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(reader)
+		}
+		return flate.NewReader(reader), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// stallTimer cancels a request once the server has kept it waiting for
+// stallTimeout.
+type stallTimer struct {
+	url     string
+	timer   *time.Timer
+	stalled atomic.Bool
+}
+
+// withStallTimeout returns a context that is cancelled when the returned
+// timer runs out. The timer starts at once, bounding the wait for the
+// response headers.
+func withStallTimeout(ctx context.Context, url string) (context.Context, *stallTimer, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stall := &stallTimer{url: url}
+	stall.timer = time.AfterFunc(stallTimeout, func() {
+		stall.stalled.Store(true)
+		cancel()
+	})
+	return ctx, stall, func() {
+		stall.timer.Stop()
+		cancel()
+	}
+}
+
+// err replaces the error of a request the timer cut short, which would
+// otherwise read as a plain cancellation.
+func (s *stallTimer) err(err error) error {
+	if err != nil && s.stalled.Load() {
+		return fmt.Errorf("%s: no response from the server for %s", s.url, stallTimeout)
+	}
+	return err
+}
+
+// body times each read of the response body. The timer is stopped in
+// between, so the time the caller spends on what was read is not counted.
+func (s *stallTimer) body(body io.ReadCloser) io.ReadCloser {
+	s.timer.Stop()
+	return &stallReader{ReadCloser: body, stall: s}
+}
+
+type stallReader struct {
+	io.ReadCloser
+	stall *stallTimer
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	r.stall.timer.Reset(stallTimeout)
+	n, err := r.ReadCloser.Read(p)
+	r.stall.timer.Stop()
+	return n, r.stall.err(err)
+}
@@ -0,0 +1,198 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setStallTimeout lowers stallTimeout for the test.
+func setStallTimeout(t *testing.T, timeout time.Duration) {
+	t.Helper()
+	saved := stallTimeout
+	stallTimeout = timeout
+	t.Cleanup(func() { stallTimeout = saved })
+}
+
+func TestFetchStallTimeout(t *testing.T) {
+	setStallTimeout(t, 100*time.Millisecond)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss><channel><title>Site</title>` + strings.Repeat(`<item><title>x</title></item>`, 5) + `</channel></rss>`))
+	})
+	mux.HandleFunc("/hang", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss><channel><title>Site</title><item><title>x</title></item>`))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Storing the items takes far longer than the timeout, which must not
+	// count against the server.
+	seen := 0
+	_, err := Fetch(context.Background(), FetchRequest{
+		URL: server.URL + "/feed",
+		OnItem: func(RSSItem) error {
+			seen++
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		},
+	})
+	if err != nil || seen != 5 {
+		t.Errorf("slow handler: err = %v, %d items; want no error and 5 items", err, seen)
+	}
+
+	start := time.Now()
+	_, err = Fetch(context.Background(), FetchRequest{URL: server.URL + "/hang"})
+	if err == nil || !strings.Contains(err.Error(), "no response from the server") {
+		t.Errorf("stalled server: err = %v, want a stall error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stalled server took %s to give up", elapsed)
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss><channel><title>Site</title></channel></rss>`))
+	})
+	redirect := func(from, to string, status int) {
+		mux.HandleFunc(from, func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, to, status)
+		})
+	}
+	redirect("/moved", "/feed", http.StatusMovedPermanently)
+	redirect("/permanent", "/feed", http.StatusPermanentRedirect)
+	redirect("/found", "/feed", http.StatusFound)
+	redirect("/temporary", "/feed", http.StatusTemporaryRedirect)
+	redirect("/moved-then-found", "/found", http.StatusMovedPermanently)
+	redirect("/found-then-moved", "/moved", http.StatusFound)
+	redirect("/moved-twice", "/permanent", http.StatusMovedPermanently)
+	redirect("/loop", "/loop", http.StatusMovedPermanently)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		path          string
+		wantPermanent bool
+	}{
+		{name: "no redirect", path: "/feed"},
+		{name: "301", path: "/moved", wantPermanent: true},
+		{name: "308", path: "/permanent", wantPermanent: true},
+		{name: "302", path: "/found"},
+		{name: "307", path: "/temporary"},
+		{name: "301 then 302", path: "/moved-then-found"},
+		{name: "302 then 301", path: "/found-then-moved"},
+		{name: "301 then 308", path: "/moved-twice", wantPermanent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Fetch(context.Background(), FetchRequest{URL: server.URL + tt.path})
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if result.FinalURL != server.URL+"/feed" {
+				t.Errorf("FinalURL = %s, want %s", result.FinalURL, server.URL+"/feed")
+			}
+			if result.PermanentRedirect != tt.wantPermanent {
+				t.Errorf("PermanentRedirect = %v, want %v", result.PermanentRedirect, tt.wantPermanent)
+			}
+		})
+	}
+
+	if _, err := Fetch(context.Background(), FetchRequest{URL: server.URL + "/loop"}); err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Errorf("redirect loop: err = %v, want it stopped", err)
+	}
+}
+
+func TestFetchNotModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("If-None-Match") == `"v1"` && r.URL.Path == "/with-validators":
+			w.Header().Set("ETag", `"v2"`)
+			w.Header().Set("Last-Modified", "Sat, 02 Mar 2024 00:00:00 GMT")
+			w.WriteHeader(http.StatusNotModified)
+		case r.Header.Get("If-None-Match") == `"v1"` || r.Header.Get("If-Modified-Since") != "":
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Last-Modified", "Fri, 01 Mar 2024 00:00:00 GMT")
+			w.Write([]byte(`<rss><channel><title>Site</title></channel></rss>`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name             string
+		path             string
+		etag             string
+		lastModified     string
+		wantNotModified  bool
+		wantETag         string
+		wantLastModified string
+	}{
+		{
+			name:             "unconditional",
+			path:             "/",
+			wantETag:         `"v1"`,
+			wantLastModified: "Fri, 01 Mar 2024 00:00:00 GMT",
+		},
+		{
+			name:             "304 keeps the validators sent",
+			path:             "/",
+			etag:             `"v1"`,
+			lastModified:     "Fri, 01 Mar 2024 00:00:00 GMT",
+			wantNotModified:  true,
+			wantETag:         `"v1"`,
+			wantLastModified: "Fri, 01 Mar 2024 00:00:00 GMT",
+		},
+		{
+			name:             "304 with last modified only",
+			path:             "/",
+			lastModified:     "Fri, 01 Mar 2024 00:00:00 GMT",
+			wantNotModified:  true,
+			wantLastModified: "Fri, 01 Mar 2024 00:00:00 GMT",
+		},
+		{
+			name:             "304 with new validators",
+			path:             "/with-validators",
+			etag:             `"v1"`,
+			wantNotModified:  true,
+			wantETag:         `"v2"`,
+			wantLastModified: "Sat, 02 Mar 2024 00:00:00 GMT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Fetch(context.Background(), FetchRequest{
+				URL:          server.URL + tt.path,
+				ETag:         tt.etag,
+				LastModified: tt.lastModified,
+			})
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if result.NotModified != tt.wantNotModified {
+				t.Errorf("NotModified = %v, want %v", result.NotModified, tt.wantNotModified)
+			}
+			if tt.wantNotModified && result.Feed != nil {
+				t.Error("a 304 returned a feed")
+			}
+			if !tt.wantNotModified && result.Feed == nil {
+				t.Error("no feed returned")
+			}
+			if result.ETag != tt.wantETag || result.LastModified != tt.wantLastModified {
+				t.Errorf("validators = %q, %q; want %q, %q", result.ETag, result.LastModified, tt.wantETag, tt.wantLastModified)
+			}
+		})
+	}
+}
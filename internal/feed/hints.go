@@ -0,0 +1,71 @@
+package feed
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PollingHints are the publisher's hints about how often the feed should be
+// polled, taken from RSS <ttl>, <skipHours>, <skipDays> and the syndication
+// module's sy:updatePeriod/sy:updateFrequency.
+type PollingHints struct {
+	// MinInterval is the shortest interval the publisher asks for, zero when
+	// the feed does not say.
+	MinInterval time.Duration
+	// SkipHours has bit n set when the feed should not be polled during hour
+	// n, in UTC.
+	SkipHours int32
+	// SkipDays has bit n set when the feed should not be polled on
+	// time.Weekday(n), in UTC.
+	SkipDays int32
+}
+
+var updatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func (feed *RSSFeed) PollingHints() PollingHints {
+	hints := PollingHints{}
+
+	if ttl, err := strconv.Atoi(strings.TrimSpace(feed.Channel.TTL)); err == nil && ttl > 0 {
+		hints.MinInterval = time.Duration(ttl) * time.Minute
+	}
+
+	if period, ok := updatePeriods[strings.ToLower(strings.TrimSpace(feed.Channel.UpdatePeriod))]; ok {
+		frequency, err := strconv.Atoi(strings.TrimSpace(feed.Channel.UpdateFrequency))
+		if err != nil || frequency < 1 {
+			frequency = 1
+		}
+		hints.MinInterval = max(hints.MinInterval, period/time.Duration(frequency))
+	}
+
+	for _, value := range feed.Channel.SkipHours {
+		// The spec numbers hours 0-23, but 24 is common for midnight.
+		if hour, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && hour >= 0 && hour <= 24 {
+			hints.SkipHours |= 1 << (hour % 24)
+		}
+	}
+
+	for _, value := range feed.Channel.SkipDays {
+		if day, ok := weekdays[strings.ToLower(strings.TrimSpace(value))]; ok {
+			hints.SkipDays |= 1 << day
+		}
+	}
+
+	return hints
+}
@@ -0,0 +1,37 @@
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollingHints(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		want    PollingHints
+	}{
+		{name: "no hints"},
+		{name: "ttl", channel: `<ttl> 60 </ttl>`, want: PollingHints{MinInterval: time.Hour}},
+		{name: "bad ttl ignored", channel: `<ttl>-5</ttl>`},
+		{name: "update period", channel: `<sy:updatePeriod>daily</sy:updatePeriod>`, want: PollingHints{MinInterval: 24 * time.Hour}},
+		{name: "update frequency divides the period", channel: `<sy:updatePeriod> Hourly </sy:updatePeriod><sy:updateFrequency>4</sy:updateFrequency>`, want: PollingHints{MinInterval: 15 * time.Minute}},
+		{name: "bad update frequency means once", channel: `<sy:updatePeriod>weekly</sy:updatePeriod><sy:updateFrequency>0</sy:updateFrequency>`, want: PollingHints{MinInterval: 7 * 24 * time.Hour}},
+		{name: "unknown update period ignored", channel: `<sy:updatePeriod>fortnightly</sy:updatePeriod>`},
+		{name: "longest of ttl and update period", channel: `<ttl>120</ttl><sy:updatePeriod>hourly</sy:updatePeriod>`, want: PollingHints{MinInterval: 2 * time.Hour}},
+		{name: "skip hours", channel: `<skipHours><hour>0</hour><hour> 13 </hour><hour>23</hour></skipHours>`, want: PollingHints{SkipHours: 1<<0 | 1<<13 | 1<<23}},
+		{name: "hour 24 is midnight", channel: `<skipHours><hour>24</hour></skipHours>`, want: PollingHints{SkipHours: 1 << 0}},
+		{name: "bad hours ignored", channel: `<skipHours><hour>25</hour><hour>-1</hour><hour>noon</hour></skipHours>`},
+		{name: "skip days", channel: `<skipDays><day>Saturday</day><day> sunday </day></skipDays>`, want: PollingHints{SkipDays: 1<<time.Saturday | 1<<time.Sunday}},
+		{name: "bad days ignored", channel: `<skipDays><day>Sat</day></skipDays>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, _ := parseItems(t, "", `<rss xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"><channel>`+tt.channel+`</channel></rss>`)
+			if got := feed.PollingHints(); got != tt.want {
+				t.Errorf("PollingHints() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
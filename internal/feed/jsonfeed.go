@@ -0,0 +1,211 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// jsonFeed is a JSON Feed 1.0 or 1.1 document, see https://jsonfeed.org,
+// without its items, which parseJSONFeed decodes one at a time.
+type jsonFeed struct {
+	Version     string `json:"version"`
+	Title       string `json:"title"`
+	HomePageURL string `json:"home_page_url"`
+	Description string `json:"description"`
+	Language    string `json:"language"`
+	Icon        string `json:"icon"`
+	Favicon     string `json:"favicon"`
+}
+
+type jsonFeedItem struct {
+	ID            jsonFeedID       `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Tags          []string         `json:"tags"`
+	Author        jsonFeedAuthor   `json:"author"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Attachments   []struct {
+		URL               string  `json:"url"`
+		MimeType          string  `json:"mime_type"`
+		SizeInBytes       int64   `json:"size_in_bytes"`
+		DurationInSeconds float64 `json:"duration_in_seconds"`
+	} `json:"attachments"`
+}
+
+// jsonFeedAuthor names an item's author, given in author by JSON Feed 1.0
+// and in authors by 1.1.
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// jsonFeedID is an item id. The spec requires a string but numbers are common
+// in the wild, so both are accepted.
+type jsonFeedID string
+
+func (id *jsonFeedID) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case string:
+		*id = jsonFeedID(v)
+	case float64:
+		*id = jsonFeedID(strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return nil
+}
+
+// isJSONFeed reports whether the response is a JSON Feed, either by its
+// declared content type or, since many servers send text/plain, by sniffing
+// the start of the body for a JSON object.
+func isJSONFeed(contentType string, head []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if mediaType == "application/feed+json" || mediaType == "application/json" {
+			return true
+		}
+	}
+
+	body := bytes.TrimLeft(head, " \t\r\n\ufeff")
+	return len(body) > 0 && body[0] == '{'
+}
+
+// parseJSONFeed decodes a JSON Feed token by token, passing each item to
+// handle as soon as it is decoded. The top level members other than items are
+// small and decoded whole.
+func parseJSONFeed(body io.Reader, handle itemHandler) (*RSSFeed, error) {
+	decoder := json.NewDecoder(body)
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('{') {
+		return nil, errors.New("JSON Feed is not an object")
+	}
+
+	f := jsonFeed{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+
+		if key != "items" {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+			member, err := json.Marshal(map[string]json.RawMessage{key: value})
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(member, &f); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			continue
+		}
+		if token != json.Delim('[') {
+			return nil, errors.New("JSON Feed items is not an array")
+		}
+		for decoder.More() {
+			item := jsonFeedItem{}
+			if err := decoder.Decode(&item); err != nil {
+				return nil, err
+			}
+			if err := handle(f.toRSS(), item.toRSS()); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	return f.toRSS(), nil
+}
+
+// toRSS normalizes the top level members of the JSON Feed document into the
+// RSSFeed model that the rest of the app stores.
+func (f *jsonFeed) toRSS() *RSSFeed {
+	feed := RSSFeed{}
+	feed.Channel.Title = f.Title
+	feed.Channel.Link = f.HomePageURL
+	feed.Channel.Description = f.Description
+	feed.Channel.Language = f.Language
+	feed.Channel.Image.URL = f.Icon
+	if feed.Channel.Image.URL == "" {
+		feed.Channel.Image.URL = f.Favicon
+	}
+	return &feed
+}
+
+func (item jsonFeedItem) toRSS() RSSItem {
+	link := item.URL
+	if link == "" {
+		link = item.ExternalURL
+	}
+
+	content := item.ContentHTML
+	if content == "" {
+		content = item.ContentText
+	}
+	description := item.Summary
+	if description == "" {
+		description = content
+	}
+
+	published := item.DatePublished
+	if published == "" {
+		published = item.DateModified
+	}
+
+	// Attachments carry a duration, which only Media RSS content has room
+	// for in the common model.
+	var media []mediaContent
+	for _, attachment := range item.Attachments {
+		media = append(media, mediaContent{
+			URL:      attachment.URL,
+			Type:     attachment.MimeType,
+			FileSize: strconv.FormatInt(attachment.SizeInBytes, 10),
+			Duration: strconv.Itoa(int(attachment.DurationInSeconds)),
+		})
+	}
+
+	creator := item.Author.Name
+	if len(item.Authors) > 0 {
+		creator = item.Authors[0].Name
+	}
+
+	return RSSItem{
+		Title:        strings.TrimSpace(item.Title),
+		Creator:      strings.TrimSpace(creator),
+		Categories:   item.Tags,
+		Link:         link,
+		Description:  description,
+		Content:      content,
+		PubDate:      published,
+		GUID:         strings.TrimSpace(string(item.ID)),
+		MediaContent: media,
+	}
+}
@@ -0,0 +1,140 @@
+package feed
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultTrackingParams are the query parameters stripped from item links
+// when a fetch does not name its own. A trailing * matches any suffix.
+var DefaultTrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+	"igshid",
+	"_hsenc",
+	"_hsmi",
+}
+
+// resolveReference resolves ref against base. base must be absolute for the
+// result to be; when either does not parse ref is returned unchanged.
+func resolveReference(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == "" || ref == "" {
+		return ref
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// resolveBase applies the xml:base values in order, innermost last, to the
+// document URL.
+func resolveBase(documentURL string, bases ...string) string {
+	base := documentURL
+	for _, next := range bases {
+		if next = strings.TrimSpace(next); next != "" {
+			base = resolveReference(base, next)
+		}
+	}
+	return base
+}
+
+// channelBase is the base URL items of the feed resolve against: the URL the
+// document was fetched from with the xml:base values in scope applied.
+func (feed *RSSFeed) channelBase(documentURL string) string {
+	return resolveBase(documentURL, feed.Base, feed.Channel.Base)
+}
+
+// resolveLinks makes the channel links of the feed absolute.
+func (feed *RSSFeed) resolveLinks(documentURL string) {
+	base := feed.channelBase(documentURL)
+	feed.Channel.Link = resolveReference(base, feed.Channel.Link)
+	feed.Channel.Image.URL = resolveReference(base, feed.Channel.Image.URL)
+}
+
+// resolveLinks makes the links of the item absolute, resolving them against
+// the item's own xml:base, which itself is resolved against channelBase.
+func (item *RSSItem) resolveLinks(channelBase string) {
+	itemBase := resolveBase(channelBase, item.Base)
+	item.Link = resolveReference(resolveBase(itemBase, item.linkBase), item.Link)
+	for i := range item.Enclosures {
+		item.Enclosures[i].URL = resolveReference(itemBase, item.Enclosures[i].URL)
+	}
+	for i := range item.MediaContent {
+		item.MediaContent[i].URL = resolveReference(itemBase, item.MediaContent[i].URL)
+	}
+	for i := range item.MediaGroup {
+		item.MediaGroup[i].URL = resolveReference(itemBase, item.MediaGroup[i].URL)
+	}
+}
+
+// canonicalizeLink replaces the item link with its canonical form, keeping
+// the link as published in OriginalLink.
+func (item *RSSItem) canonicalizeLink(trackingParams []string) {
+	item.OriginalLink = item.Link
+	item.Link = CanonicalURL(item.Link, trackingParams)
+}
+
+// CanonicalURL normalizes an absolute URL so the same page is spelled the
+// same way: scheme and host are lower-cased, the default port is dropped and
+// query parameters matching trackingParams are removed. Other parameters
+// keep their order and encoding. Anything that is not an absolute http(s)
+// URL is returned unchanged.
+func CanonicalURL(rawURL string, trackingParams []string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return rawURL
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); port == "80" && u.Scheme == "http" || port == "443" && u.Scheme == "https" {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+
+	if u.RawQuery != "" {
+		var kept []string
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			name, _, _ := strings.Cut(param, "=")
+			if unescaped, err := url.QueryUnescape(name); err == nil {
+				name = unescaped
+			}
+			if param != "" && !isTrackingParam(name, trackingParams) {
+				kept = append(kept, param)
+			}
+		}
+		u.RawQuery = strings.Join(kept, "&")
+	}
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+func isTrackingParam(name string, trackingParams []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range trackingParams {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
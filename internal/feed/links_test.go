@@ -0,0 +1,167 @@
+package feed
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"already canonical", "https://example.com/post?id=1", "https://example.com/post?id=1"},
+		{"scheme and host lower-cased", "HTTPS://Example.COM/Post", "https://example.com/Post"},
+		{"default https port dropped", "https://example.com:443/post", "https://example.com/post"},
+		{"default http port dropped", "http://example.com:80/post", "http://example.com/post"},
+		{"other port kept", "https://example.com:8443/post", "https://example.com:8443/post"},
+		{"http port kept on https", "https://example.com:80/post", "https://example.com:80/post"},
+		{"tracking params removed", "https://example.com/post?utm_source=rss&id=1&utm_medium=feed&fbclid=x", "https://example.com/post?id=1"},
+		{"only tracking params", "https://example.com/post?utm_source=rss", "https://example.com/post"},
+		{"tracking param names ignore case", "https://example.com/post?UTM_Source=rss&GCLID=1", "https://example.com/post"},
+		{"escaped tracking param name", "https://example.com/post?utm%5Fsource=rss&a=1", "https://example.com/post?a=1"},
+		{"order and encoding of others kept", "https://example.com/post?b=2&a=%2F&utm_campaign=x&c", "https://example.com/post?b=2&a=%2F&c"},
+		{"empty params dropped", "https://example.com/post?&a=1&&", "https://example.com/post?a=1"},
+		{"fragment kept", "https://example.com/post?utm_source=x#comments", "https://example.com/post#comments"},
+		{"trailing question mark dropped", "https://example.com/post?", "https://example.com/post"},
+		{"relative url unchanged", "/post?utm_source=x", "/post?utm_source=x"},
+		{"other scheme unchanged", "mailto:Someone@Example.com?utm_source=x", "mailto:Someone@Example.com?utm_source=x"},
+		{"unparseable unchanged", "https://exa mple.com/%zz", "https://exa mple.com/%zz"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalURL(tt.url, DefaultTrackingParams); got != tt.want {
+				t.Errorf("CanonicalURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalURLTrackingParams(t *testing.T) {
+	const link = "https://example.com/post?utm_source=rss&ref=home&src=feed"
+	tests := []struct {
+		name           string
+		trackingParams []string
+		want           string
+	}{
+		{"none removes nothing", []string{}, link},
+		{"exact name", []string{"ref"}, "https://example.com/post?utm_source=rss&src=feed"},
+		{"prefix", []string{"sr*"}, "https://example.com/post?utm_source=rss&ref=home"},
+		{"match everything", []string{"*"}, "https://example.com/post"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalURL(link, tt.trackingParams); got != tt.want {
+				t.Errorf("CanonicalURL with %v = %q, want %q", tt.trackingParams, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLinks(t *testing.T) {
+	const documentURL = "https://example.com/blog/feed.xml"
+	tests := []struct {
+		name        string
+		feedBase    string
+		channelBase string
+		itemBase    string
+		linkBase    string
+		link        string
+		want        string
+	}{
+		{name: "absolute link", link: "https://other.org/post", want: "https://other.org/post"},
+		{name: "relative to the document", link: "posts/1", want: "https://example.com/blog/posts/1"},
+		{name: "root relative", link: "/posts/1", want: "https://example.com/posts/1"},
+		{name: "protocol relative", link: "//cdn.example.com/1", want: "https://cdn.example.com/1"},
+		{name: "surrounding space trimmed", link: "  posts/1\n", want: "https://example.com/blog/posts/1"},
+		{name: "empty link stays empty", link: "", want: ""},
+		{name: "feed base", feedBase: "https://mirror.example.net/", link: "posts/1", want: "https://mirror.example.net/posts/1"},
+		{name: "relative channel base", channelBase: "../archive/", link: "1", want: "https://example.com/archive/1"},
+		{name: "nested bases", feedBase: "https://a.example/x/", channelBase: "y/", itemBase: "z/", link: "1", want: "https://a.example/x/y/z/1"},
+		{name: "link base applies last", itemBase: "/items/", linkBase: "2024/", link: "1", want: "https://example.com/items/2024/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := RSSFeed{Base: tt.feedBase}
+			feed.Channel.Base = tt.channelBase
+			item := RSSItem{Base: tt.itemBase, linkBase: tt.linkBase, Link: tt.link}
+			item.resolveLinks(feed.channelBase(documentURL))
+			if item.Link != tt.want {
+				t.Errorf("link = %q, want %q", item.Link, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLinksEnclosuresAndChannel(t *testing.T) {
+	feed := RSSFeed{}
+	feed.Channel.Base = "https://example.com/podcast/"
+	feed.Channel.Link = "/"
+	feed.Channel.Image.URL = "cover.png"
+	feed.resolveLinks("https://feeds.example.com/rss")
+	if feed.Channel.Link != "https://example.com/" || feed.Channel.Image.URL != "https://example.com/podcast/cover.png" {
+		t.Errorf("channel link, image = %q, %q", feed.Channel.Link, feed.Channel.Image.URL)
+	}
+
+	item := RSSItem{Base: "episodes/", linkBase: "ignored/", Link: "1"}
+	item.Enclosures = []rssEnclosure{{URL: "1.mp3"}}
+	item.resolveLinks(feed.channelBase("https://feeds.example.com/rss"))
+	if got := item.Enclosures[0].URL; got != "https://example.com/podcast/episodes/1.mp3" {
+		t.Errorf("enclosure = %q, want it resolved against the item base only", got)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	tests := []struct {
+		name       string
+		item       RSSItem
+		wantKey    string
+		wantLegacy string
+	}{
+		{
+			name:       "guid wins",
+			item:       RSSItem{GUID: " tag:1 ", Link: "https://example.com/1", OriginalLink: "https://example.com/1?utm_source=x"},
+			wantKey:    "tag:1",
+			wantLegacy: "https://example.com/1?utm_source=x",
+		},
+		{
+			name:    "guid that is the link",
+			item:    RSSItem{GUID: "https://example.com/1", Link: "https://example.com/1", OriginalLink: "https://example.com/1"},
+			wantKey: "https://example.com/1",
+		},
+		{
+			name:    "guid without a link",
+			item:    RSSItem{GUID: "tag:1"},
+			wantKey: "tag:1",
+		},
+		{
+			name:       "canonical link without a guid",
+			item:       RSSItem{Link: "https://example.com/1", OriginalLink: "https://Example.com/1?utm_source=x"},
+			wantKey:    "https://example.com/1",
+			wantLegacy: "https://Example.com/1?utm_source=x",
+		},
+		{
+			name:    "link already canonical",
+			item:    RSSItem{Link: "https://example.com/1", OriginalLink: "https://example.com/1"},
+			wantKey: "https://example.com/1",
+		},
+		{
+			name:    "title without a link",
+			item:    RSSItem{Title: " Hello "},
+			wantKey: "Hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.Identity(); got != tt.wantKey {
+				t.Errorf("Identity = %q, want %q", got, tt.wantKey)
+			}
+			if got := tt.item.LegacyIdentity(); got != tt.wantLegacy {
+				t.Errorf("LegacyIdentity = %q, want %q", got, tt.wantLegacy)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package feed
+
+import (
+	"mime"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rssEnclosure is an RSS 2.0 <enclosure>. Atom enclosure links and JSON Feed
+// attachments are converted to it too.
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// mediaContent is a Media RSS <media:content>.
+type mediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+// Enclosure is a media file attached to an item. Length and Duration are zero
+// when the feed does not say.
+type Enclosure struct {
+	URL      string
+	Type     string
+	Length   int64
+	Duration time.Duration
+}
+
+// IsAudioVideo reports whether the enclosure is a podcast episode or video
+// rather than, say, an image.
+func (e Enclosure) IsAudioVideo() bool {
+	return strings.HasPrefix(e.Type, "audio/") || strings.HasPrefix(e.Type, "video/")
+}
+
+// Media returns the item's enclosures and Media RSS content, each URL once.
+// The itunes:duration of the item applies to audio and video that carry none
+// of their own.
+func (item RSSItem) Media() []Enclosure {
+	var media []Enclosure
+	seen := map[string]bool{}
+	itemDuration := parseMediaDuration(item.Duration)
+	add := func(enclosure Enclosure) {
+		enclosure.URL = strings.TrimSpace(enclosure.URL)
+		if enclosure.URL == "" || seen[enclosure.URL] {
+			return
+		}
+		seen[enclosure.URL] = true
+		if enclosure.Type == "" {
+			enclosure.Type = typeByExtension(enclosure.URL)
+		}
+		if enclosure.Duration == 0 && enclosure.IsAudioVideo() {
+			enclosure.Duration = itemDuration
+		}
+		media = append(media, enclosure)
+	}
+
+	for _, enclosure := range item.Enclosures {
+		add(Enclosure{
+			URL:    enclosure.URL,
+			Type:   mediaType(enclosure.Type),
+			Length: parseLength(enclosure.Length),
+		})
+	}
+	for _, content := range slices.Concat(item.MediaContent, item.MediaGroup) {
+		add(Enclosure{
+			URL:      content.URL,
+			Type:     mediaType(content.Type),
+			Length:   parseLength(content.FileSize),
+			Duration: parseMediaDuration(content.Duration),
+		})
+	}
+	return media
+}
+
+// EpisodeNumber returns the itunes:episode of the item, if it has one.
+func (item RSSItem) EpisodeNumber() (int32, bool) {
+	episode, err := strconv.ParseInt(strings.TrimSpace(item.Episode), 10, 32)
+	if err != nil || episode < 0 {
+		return 0, false
+	}
+	return int32(episode), true
+}
+
+// mediaType strips parameters from a MIME type and lower-cases it.
+func mediaType(value string) string {
+	if parsed, _, err := mime.ParseMediaType(value); err == nil {
+		return parsed
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// mediaExtensions are the types of common podcast and video files. Go has no
+// built-in entry for most of them and the system's mime.types may be missing,
+// so they are not left to mime.TypeByExtension.
+var mediaExtensions = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".opus": "audio/opus",
+	".flac": "audio/flac",
+	".wav":  "audio/wav",
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+}
+
+// typeByExtension guesses the MIME type of an enclosure that does not state
+// one from the extension of its URL.
+func typeByExtension(enclosureURL string) string {
+	u, err := url.Parse(enclosureURL)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	if known, ok := mediaExtensions[ext]; ok {
+		return known
+	}
+	return mediaType(mime.TypeByExtension(ext))
+}
+
+func parseLength(value string) int64 {
+	length, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || length < 0 {
+		return 0
+	}
+	return length
+}
+
+// parseMediaDuration reads a duration given as seconds, MM:SS or HH:MM:SS,
+// the forms itunes:duration takes in the wild. Fractional seconds are
+// dropped.
+func parseMediaDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0
+	}
+	var seconds int64
+	for _, part := range parts {
+		whole, _, _ := strings.Cut(part, ".")
+		n, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds) * time.Second
+}
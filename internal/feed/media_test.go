@@ -0,0 +1,128 @@
+package feed
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseMediaDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"45", 45 * time.Second},
+		{"3600", time.Hour},
+		{"4:05", 4*time.Minute + 5*time.Second},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"90:00", 90 * time.Minute},
+		{" 12:34 ", 12*time.Minute + 34*time.Second},
+		{"1:02:03.500", time.Hour + 2*time.Minute + 3*time.Second},
+		{"61.9", 61 * time.Second},
+		{"1:2:3:4", 0},
+		{"1h30m", 0},
+		{"-5", 0},
+		{"1:-5", 0},
+		{"1::2", 0},
+		{"abc", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseMediaDuration(tt.value); got != tt.want {
+			t.Errorf("parseMediaDuration(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMedia(t *testing.T) {
+	tests := []struct {
+		name string
+		item RSSItem
+		want []Enclosure
+	}{
+		{
+			name: "no media",
+			item: RSSItem{},
+		},
+		{
+			name: "enclosure",
+			item: RSSItem{Enclosures: []rssEnclosure{{URL: " https://cdn.example/1.mp3 ", Type: "Audio/MPEG; charset=binary", Length: "1234"}}},
+			want: []Enclosure{{URL: "https://cdn.example/1.mp3", Type: "audio/mpeg", Length: 1234}},
+		},
+		{
+			name: "item duration applies to audio without its own",
+			item: RSSItem{Duration: "1:00", Enclosures: []rssEnclosure{{URL: "https://cdn.example/1.mp3", Type: "audio/mpeg"}}},
+			want: []Enclosure{{URL: "https://cdn.example/1.mp3", Type: "audio/mpeg", Duration: time.Minute}},
+		},
+		{
+			name: "item duration does not apply to images",
+			item: RSSItem{Duration: "1:00", Enclosures: []rssEnclosure{{URL: "https://cdn.example/1.jpg", Type: "image/jpeg"}}},
+			want: []Enclosure{{URL: "https://cdn.example/1.jpg", Type: "image/jpeg"}},
+		},
+		{
+			name: "media content keeps its own duration",
+			item: RSSItem{Duration: "1:00", MediaContent: []mediaContent{{URL: "https://cdn.example/1.mp4", Type: "video/mp4", FileSize: "99", Duration: "30"}}},
+			want: []Enclosure{{URL: "https://cdn.example/1.mp4", Type: "video/mp4", Length: 99, Duration: 30 * time.Second}},
+		},
+		{
+			name: "each url once across enclosures and media content",
+			item: RSSItem{
+				Enclosures:   []rssEnclosure{{URL: "https://cdn.example/1.mp3", Type: "audio/mpeg", Length: "10"}},
+				MediaContent: []mediaContent{{URL: "https://cdn.example/1.mp3", Type: "audio/mpeg", Duration: "5"}},
+				MediaGroup:   []mediaContent{{URL: "https://cdn.example/1.ogg", Type: "audio/ogg"}, {URL: "https://cdn.example/1.mp3"}},
+			},
+			want: []Enclosure{
+				{URL: "https://cdn.example/1.mp3", Type: "audio/mpeg", Length: 10},
+				{URL: "https://cdn.example/1.ogg", Type: "audio/ogg"},
+			},
+		},
+		{
+			name: "type guessed from the extension",
+			item: RSSItem{Duration: "42", Enclosures: []rssEnclosure{{URL: "https://cdn.example/ep.M4A?token=x"}, {URL: "https://cdn.example/ep.opus"}}},
+			want: []Enclosure{
+				{URL: "https://cdn.example/ep.M4A?token=x", Type: "audio/mp4", Duration: 42 * time.Second},
+				{URL: "https://cdn.example/ep.opus", Type: "audio/opus", Duration: 42 * time.Second},
+			},
+		},
+		{
+			name: "empty urls and bad lengths skipped",
+			item: RSSItem{Enclosures: []rssEnclosure{{URL: " "}, {URL: "https://cdn.example/1.mp3", Length: "-1"}}},
+			want: []Enclosure{{URL: "https://cdn.example/1.mp3", Type: "audio/mpeg"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.item.Media()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Media() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEpisodeNumber(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   int32
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"12", 12, true},
+		{" 7 ", 7, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"3a", 0, false},
+		{"99999999999", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := RSSItem{Episode: tt.value}.EpisodeNumber()
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("EpisodeNumber(%q) = %d, %v; want %d, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package feed
+
+import "strings"
+
+// rdfFeed is an RSS 1.0 document. Unlike RSS 2.0 the items are siblings of
+// the channel rather than children of it.
+type rdfFeed struct {
+	Channel struct {
+		Title           string `xml:"title"`
+		Link            string `xml:"link"`
+		Description     string `xml:"description"`
+		Language        string `xml:"http://purl.org/dc/elements/1.1/ language"`
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	} `xml:"channel"`
+	Image struct {
+		URL string `xml:"url"`
+	} `xml:"image"`
+	Items itemSink[rdfItem] `xml:"item"`
+}
+
+type rdfItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+// toRSS normalizes the channel of the RSS 1.0 document into the RSSFeed model
+// that the rest of the app stores. Items are converted one by one as they are
+// decoded.
+func (f *rdfFeed) toRSS() *RSSFeed {
+	feed := RSSFeed{}
+	feed.Channel.Title = strings.TrimSpace(f.Channel.Title)
+	feed.Channel.Link = strings.TrimSpace(f.Channel.Link)
+	feed.Channel.Description = strings.TrimSpace(f.Channel.Description)
+	feed.Channel.Language = strings.TrimSpace(f.Channel.Language)
+	feed.Channel.Image.URL = strings.TrimSpace(f.Image.URL)
+	feed.Channel.UpdatePeriod = f.Channel.UpdatePeriod
+	feed.Channel.UpdateFrequency = f.Channel.UpdateFrequency
+	feed.unescape()
+	return &feed
+}
+
+func (item rdfItem) toRSS() RSSItem {
+	return RSSItem{
+		Title:       strings.TrimSpace(item.Title),
+		Link:        strings.TrimSpace(item.Link),
+		Description: item.Description,
+		Content:     item.Content,
+		PubDate:     item.Date,
+		Creator:     strings.TrimSpace(item.Creator),
+		Subjects:    item.Subjects,
+		GUID:        strings.TrimSpace(item.About),
+	}
+}
@@ -1,65 +1,282 @@
 package feed
 
 import (
-	"context"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
+	"fmt"
 	"html"
 	"io"
-	"net/http"
-	"time"
+	"slices"
+	"strings"
 )
 
 type RSSFeed struct {
-	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
-	} `xml:"channel"`
+	Base    string     `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Channel RSSChannel `xml:"channel"`
 }
 
-type RSSItem struct {
+type RSSChannel struct {
+	Base        string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Language    string `xml:"language"`
+	Image       struct {
+		URL string `xml:"url"`
+	} `xml:"image"`
+	TTL             string    `xml:"ttl"`
+	SkipHours       []string  `xml:"skipHours>hour"`
+	SkipDays        []string  `xml:"skipDays>day"`
+	UpdatePeriod    string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	Item            []RSSItem `xml:"item"`
 }
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	httpClient := http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+type RSSItem struct {
+	Base         string         `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title        string         `xml:"title"`
+	Link         string         `xml:"link"`
+	Description  string         `xml:"description"`
+	Content      string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate      string         `xml:"pubDate"`
+	Author       string         `xml:"author"`
+	Creator      string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories   []string       `xml:"category"`
+	Subjects     []string       `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	GUID         string         `xml:"guid"`
+	Enclosures   []rssEnclosure `xml:"enclosure"`
+	MediaContent []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroup   []mediaContent `xml:"http://search.yahoo.com/mrss/ group>content"`
+	Duration     string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Episode      string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+
+	// OriginalLink is Link as the feed published it, before CanonicalURL.
+	OriginalLink string `xml:"-"`
+	// linkBase is an xml:base on the link element itself, which only Atom
+	// allows.
+	linkBase string
+}
 
-	if err != nil {
-		return nil, err
+// Identity returns the key that identifies the item within its feed: the
+// guid when the feed provides one, otherwise the canonical link, otherwise the
+// title. Keying on the canonical link keeps an item whose tracking parameters
+// change between fetches from being stored again.
+func (item RSSItem) Identity() string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
 	}
+	if link := strings.TrimSpace(item.Link); link != "" {
+		return link
+	}
+	return strings.TrimSpace(item.Title)
+}
 
-	req.Header.Set("User-Agent", "gator")
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
+// LegacyIdentity returns the key older rows may hold for the item, the link
+// as published, or "" when that is the same as Identity. Posts stored before
+// guids were tracked were keyed on their link whether or not the item has a
+// guid, and posts without one were keyed on the link as published until
+// links were canonicalized.
+func (item RSSItem) LegacyIdentity() string {
+	link := strings.TrimSpace(item.OriginalLink)
+	if link == "" {
+		link = strings.TrimSpace(item.Link)
 	}
-	defer resp.Body.Close()
+	if link == "" || link == item.Identity() {
+		return ""
+	}
+	return link
+}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// AuthorName returns who wrote the item. dc:creator holds a plain name and is
+// preferred; RSS 2.0 <author> is an email address, optionally followed by the
+// name in parentheses, which is used when present.
+func (item RSSItem) AuthorName() string {
+	if creator := strings.TrimSpace(item.Creator); creator != "" {
+		return creator
 	}
 
-	feed := RSSFeed{}
-	err = xml.Unmarshal(data, &feed)
+	author := strings.TrimSpace(item.Author)
+	if open := strings.Index(author, "("); open > 0 && strings.HasSuffix(author, ")") {
+		if name := strings.TrimSpace(author[open+1 : len(author)-1]); name != "" {
+			return name
+		}
+	}
+	return author
+}
 
-	if err != nil {
-		return nil, err
+// CategoryNames returns the item's categories and dc:subjects, each once
+// regardless of case.
+func (item RSSItem) CategoryNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range append(slices.Clone(item.Categories), item.Subjects...) {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// Metadata describes the site a feed belongs to.
+type Metadata struct {
+	SiteURL     string
+	Description string
+	Language    string
+	ImageURL    string
+}
+
+// Metadata returns the channel level details of the feed.
+func (feed *RSSFeed) Metadata() Metadata {
+	return Metadata{
+		SiteURL:     strings.TrimSpace(feed.Channel.Link),
+		Description: strings.TrimSpace(feed.Channel.Description),
+		Language:    strings.TrimSpace(feed.Channel.Language),
+		ImageURL:    strings.TrimSpace(feed.Channel.Image.URL),
 	}
+}
+
+// ContentHash fingerprints the parts of the item an author may edit after
+// publishing, so a changed item can be told apart from one already stored.
+// Items without a full body hash as they did before it was stored, so they
+// are not mistaken for edited ones.
+func (item RSSItem) ContentHash() string {
+	fingerprint := item.Title + "\x00" + item.Description
+	if item.Content != "" {
+		fingerprint += "\x00" + item.Content
+	}
+	sum := sha256.Sum256([]byte(fingerprint))
+	return hex.EncodeToString(sum[:])
+}
 
+// unescape decodes HTML entities left in the text fields after parsing. RSS
+// carries HTML in these fields escaped once more than the XML calls for, so
+// this applies to RSS 2.0 and 1.0 only; Atom and JSON Feed say what their
+// fields hold and are converted accordingly.
+func (feed *RSSFeed) unescape() {
 	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
 	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
+}
+
+// unescape is RSSFeed.unescape for an item.
+func (item *RSSItem) unescape() {
+	item.Title = html.UnescapeString(item.Title)
+	item.Description = html.UnescapeString(item.Description)
+}
+
+// itemHandler receives each item of a feed as soon as it is decoded, along
+// with the feed as decoded so far. An error stops the parse and is returned
+// from it.
+type itemHandler func(feed *RSSFeed, item RSSItem) error
+
+// itemSink stands in for the list of items or entries in a document type, so
+// the decoder hands over each element as it is read instead of collecting
+// them all. An error from handle stops the decoder.
+type itemSink[T any] struct {
+	handle func(T) error
+}
+
+func (s itemSink[T]) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	var element T
+	if err := decoder.DecodeElement(&element, &start); err != nil {
+		return err
+	}
+	return s.handle(element)
+}
+
+// rssDocument is an RSS 2.0 document as it is decoded, with the items passed
+// on rather than kept in Channel.Item.
+type rssDocument struct {
+	Base    string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Channel struct {
+		RSSChannel
+		Item itemSink[RSSItem] `xml:"item"`
+	} `xml:"channel"`
+}
+
+func (d *rssDocument) toRSS() *RSSFeed {
+	feed := &RSSFeed{Base: d.Base, Channel: d.Channel.RSSChannel}
+	feed.unescape()
+	return feed
+}
+
+// sniffLen is how much of the body is looked at to tell JSON from XML.
+const sniffLen = 512
+
+// parseFeed detects the feed format from the content type or the document
+// itself and decodes it into the common RSSFeed model. The body is decoded
+// token by token as it is read, and each item is passed to handle as soon as
+// it is complete rather than collected, so memory use is bounded by the
+// largest item, not the document. The returned feed holds the channel alone.
+func parseFeed(contentType string, body io.Reader, handle itemHandler) (*RSSFeed, error) {
+	reader := bufio.NewReaderSize(body, sniffLen)
+	// A short body makes Peek fail, but what it did return is enough to sniff.
+	head, _ := reader.Peek(sniffLen)
+
+	if isJSONFeed(contentType, head) {
+		return parseJSONFeed(reader, handle)
+	}
+
+	decoder, err := newXMLDecoder(contentType, reader)
+	if err != nil {
+		return nil, err
+	}
+	root, err := rootElement(decoder)
+	if err != nil {
+		return nil, err
+	}
 
-	for i, item := range feed.Channel.Item {
-		item.Title = html.UnescapeString(item.Title)
-		item.Description = html.UnescapeString(item.Description)
-		feed.Channel.Item[i] = item
+	switch root.Name.Local {
+	case "rss":
+		doc := rssDocument{}
+		doc.Channel.Item.handle = func(item RSSItem) error {
+			item.unescape()
+			return handle(doc.toRSS(), item)
+		}
+		if err := decoder.DecodeElement(&doc, &root); err != nil {
+			return nil, err
+		}
+		return doc.toRSS(), nil
+	case "RDF":
+		doc := rdfFeed{}
+		doc.Items.handle = func(entry rdfItem) error {
+			item := entry.toRSS()
+			item.unescape()
+			return handle(doc.toRSS(), item)
+		}
+		if err := decoder.DecodeElement(&doc, &root); err != nil {
+			return nil, err
+		}
+		return doc.toRSS(), nil
+	case "feed":
+		doc := atomFeed{}
+		doc.Entries.handle = func(entry atomEntry) error {
+			return handle(doc.toRSS(), doc.item(entry))
+		}
+		if err := decoder.DecodeElement(&doc, &root); err != nil {
+			return nil, err
+		}
+		return doc.toRSS(), nil
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", root.Name.Local)
 	}
+}
 
-	return &feed, nil
+// rootElement reads tokens up to the document element, skipping the prolog,
+// and leaves the decoder positioned just inside it.
+func rootElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, fmt.Errorf("failed to find root element: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
 }
@@ -0,0 +1,218 @@
+package feed
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// parseItems parses body, collecting the items handed over.
+func parseItems(t *testing.T, contentType, body string) (*RSSFeed, []RSSItem) {
+	t.Helper()
+	var items []RSSItem
+	feed, err := parseFeed(contentType, strings.NewReader(body), func(_ *RSSFeed, item RSSItem) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parseFeed: %v", err)
+	}
+	return feed, items
+}
+
+func TestParseFeedEscaping(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentType     string
+		body            string
+		wantTitle       string
+		wantDescription string
+	}{
+		{
+			name:            "rss double escaped html",
+			body:            `<rss><channel><item><title>Tom &amp;amp; Jerry</title><description>&lt;p&gt;&amp;lt;div&amp;gt;&lt;/p&gt;</description></item></channel></rss>`,
+			wantTitle:       "Tom & Jerry",
+			wantDescription: "<p><div></p>",
+		},
+		{
+			name:            "rdf double escaped html",
+			body:            `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"><item><title>A &amp;amp; B</title><description>&lt;b&gt;x&lt;/b&gt;</description></item></rdf:RDF>`,
+			wantTitle:       "A & B",
+			wantDescription: "<b>x</b>",
+		},
+		{
+			name:            "json content_html keeps its entities",
+			contentType:     "application/feed+json",
+			body:            `{"version":"https://jsonfeed.org/version/1.1","items":[{"id":"1","title":"Tom &amp; Jerry","content_html":"<p>&lt;div&gt;</p>"}]}`,
+			wantTitle:       "Tom &amp; Jerry",
+			wantDescription: "<p>&lt;div&gt;</p>",
+		},
+		{
+			name:            "atom html content keeps its entities",
+			body:            `<feed xmlns="http://www.w3.org/2005/Atom"><entry><title type="html">Tom &amp;amp; Jerry</title><content type="html">&lt;p&gt;&amp;lt;div&amp;gt;&lt;/p&gt;</content></entry></feed>`,
+			wantTitle:       "Tom & Jerry",
+			wantDescription: "<p>&lt;div&gt;</p>",
+		},
+		{
+			name:            "atom text title is taken as is",
+			body:            `<feed xmlns="http://www.w3.org/2005/Atom"><entry><title>a &amp;lt; b</title><summary>s</summary></entry></feed>`,
+			wantTitle:       "a &lt; b",
+			wantDescription: "s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, items := parseItems(t, tt.contentType, tt.body)
+			if len(items) != 1 {
+				t.Fatalf("got %d items, want 1", len(items))
+			}
+			item := items[0]
+			if item.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", item.Title, tt.wantTitle)
+			}
+			if item.Description != tt.wantDescription {
+				t.Errorf("description = %q, want %q", item.Description, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestParseFeedFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name: "rss",
+			body: `<rss><channel xml:base="https://ex.com/"><title>Site</title><item><title>one</title></item><item><title>two</title></item><ttl>60</ttl></channel></rss>`,
+		},
+		{
+			name: "rdf",
+			body: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"><channel><title>Site</title></channel><item><title>one</title></item><item><title>two</title></item></rdf:RDF>`,
+		},
+		{
+			name: "atom",
+			body: `<feed xmlns="http://www.w3.org/2005/Atom"><title>Site</title><entry><title>one</title></entry><entry><title>two</title></entry></feed>`,
+		},
+		{
+			name:        "json",
+			contentType: "application/feed+json",
+			body:        `{"title":"Site","items":[{"id":"1","title":"one"},{"id":"2","title":"two"}],"description":"after the items"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, items := parseItems(t, tt.contentType, tt.body)
+			if feed.Channel.Title != "Site" {
+				t.Errorf("channel title = %q, want Site", feed.Channel.Title)
+			}
+			if len(feed.Channel.Item) != 0 {
+				t.Errorf("returned feed holds %d items, want them handed over only", len(feed.Channel.Item))
+			}
+			if len(items) != 2 || items[0].Title != "one" || items[1].Title != "two" {
+				t.Errorf("items = %+v, want one and two", items)
+			}
+		})
+	}
+}
+
+// chunkReader returns a few bytes per Read and counts how many it returned.
+type chunkReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p[:min(len(p), 16)])
+	c.read += n
+	return n, err
+}
+
+func TestParseFeedStreamsItems(t *testing.T) {
+	var body strings.Builder
+	body.WriteString(`<rss><channel><title>Site</title>`)
+	for range 1000 {
+		body.WriteString(`<item><title>an item padded out to take up some room</title></item>`)
+	}
+	body.WriteString(`</channel></rss>`)
+
+	for _, contentType := range []string{"application/rss+xml", "application/feed+json"} {
+		source := body.String()
+		if contentType == "application/feed+json" {
+			source = `{"items":[` + strings.Repeat(`{"id":"x","title":"an item padded out to take up some room"},`, 999) + `{"id":"y"}]}`
+		}
+		reader := &chunkReader{r: strings.NewReader(source)}
+		stop := errors.New("stop")
+		seen := 0
+		_, err := parseFeed(contentType, reader, func(*RSSFeed, RSSItem) error {
+			seen++
+			if seen == 2 {
+				return stop
+			}
+			return nil
+		})
+		if !errors.Is(err, stop) {
+			t.Fatalf("%s: parseFeed error = %v, want the handler's", contentType, err)
+		}
+		if reader.read > len(source)/10 {
+			t.Errorf("%s: read %d of %d bytes before the second item, want the items handed over as they arrive", contentType, reader.read, len(source))
+		}
+	}
+}
+
+func TestAuthorName(t *testing.T) {
+	tests := []struct {
+		name    string
+		creator string
+		author  string
+		want    string
+	}{
+		{name: "none"},
+		{name: "dc creator", creator: " Jane Doe ", want: "Jane Doe"},
+		{name: "dc creator preferred", creator: "Jane Doe", author: "jane@example.com (Jane D.)", want: "Jane Doe"},
+		{name: "email with name", author: "jane@example.com (Jane Doe)", want: "Jane Doe"},
+		{name: "email with spaced name", author: " jane@example.com ( Jane Doe ) ", want: "Jane Doe"},
+		{name: "email only", author: "jane@example.com", want: "jane@example.com"},
+		{name: "empty parentheses", author: "jane@example.com ()", want: "jane@example.com ()"},
+		{name: "only parentheses", author: "(Jane Doe)", want: "(Jane Doe)"},
+		{name: "plain name", author: "Jane Doe", want: "Jane Doe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := RSSItem{Creator: tt.creator, Author: tt.author}
+			if got := item.AuthorName(); got != tt.want {
+				t.Errorf("AuthorName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategoryNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		categories []string
+		subjects   []string
+		want       []string
+	}{
+		{name: "none"},
+		{name: "categories", categories: []string{"Go", " Databases "}, want: []string{"Go", "Databases"}},
+		{name: "subjects follow categories", categories: []string{"Go"}, subjects: []string{"Testing"}, want: []string{"Go", "Testing"}},
+		{name: "duplicates ignoring case keep the first", categories: []string{"Go", "go", "GO "}, subjects: []string{"gO"}, want: []string{"Go"}},
+		{name: "blank names skipped", categories: []string{"", "  "}, subjects: []string{"Go"}, want: []string{"Go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := RSSItem{Categories: tt.categories, Subjects: tt.subjects}
+			if got := item.CategoryNames(); !slices.Equal(got, tt.want) {
+				t.Errorf("CategoryNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
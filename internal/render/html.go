@@ -0,0 +1,500 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Document is an HTML fragment, such as a post description, reduced to plain
+// text blocks. Links are pulled out of the text and numbered, so a renderer
+// can show them as footnotes.
+type Document struct {
+	// Links are the absolute URLs referenced as [n] in the text, in order.
+	Links []string
+
+	blocks    []block
+	linkIndex map[string]int
+}
+
+// block is a paragraph, list item or preformatted section.
+type block struct {
+	text string
+	// indent is the indentation of the block, marker the list bullet or
+	// number its first line starts with.
+	indent int
+	marker string
+	quote  int
+	pre    bool
+	inList bool
+}
+
+// skippedElements have content that is never shown as text.
+var skippedElements = map[string]bool{
+	"head":     true,
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"math":     true,
+}
+
+// blockElements start and end a block of text.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "dd": true, "details": true,
+	"div": true, "dl": true, "dt": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "main": true, "nav": true, "p": true,
+	"section": true, "summary": true, "table": true, "tr": true, "caption": true,
+}
+
+var (
+	markup       = regexp.MustCompile(`<[a-zA-Z/!?]`)
+	blankLine    = regexp.MustCompile(`\n\s*\n`)
+	tagName      = regexp.MustCompile(`^/?([a-zA-Z][a-zA-Z0-9-]*)`)
+	tagAttribute = regexp.MustCompile(`(?s)([\w:-]+)\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+)`)
+	// escapeSequence matches ANSI CSI sequences such as colour changes, and
+	// OSC sequences such as window title changes.
+	escapeSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+)
+
+type list struct {
+	ordered bool
+	next    int
+}
+
+// parser turns HTML into a Document. It is deliberately forgiving: feeds
+// carry every kind of broken markup, and a best effort is better than
+// falling back to the raw source.
+type parser struct {
+	doc  *Document
+	base *url.URL
+
+	text   strings.Builder
+	marker string
+	lists  []list
+	quote  int
+	pre    int
+	// anchor is the link being read, and anchorText where its text starts.
+	anchor     string
+	inAnchor   bool
+	anchorText int
+}
+
+// HTML parses source into a Document. Relative links are resolved against
+// baseURL, typically the URL of the post. Text without any markup is taken
+// as plain text, with blank lines separating paragraphs.
+func HTML(source, baseURL string) *Document {
+	p := &parser{doc: &Document{linkIndex: map[string]int{}}}
+	if base, err := url.Parse(baseURL); err == nil && base.IsAbs() {
+		p.base = base
+	}
+
+	if !markup.MatchString(source) {
+		for _, paragraph := range blankLine.Split(source, -1) {
+			p.addText(html.UnescapeString(paragraph))
+			p.flush()
+		}
+		return p.doc
+	}
+
+	p.parse(source)
+	p.flush()
+	return p.doc
+}
+
+func (p *parser) parse(source string) {
+	for source != "" {
+		i := strings.IndexByte(source, '<')
+		if i < 0 {
+			p.addText(html.UnescapeString(source))
+			return
+		}
+		p.addText(html.UnescapeString(source[:i]))
+		source = source[i:]
+
+		switch {
+		case strings.HasPrefix(source, "<!--"):
+			source = after(source[4:], "-->")
+		case strings.HasPrefix(source, "<![CDATA["):
+			data, rest, _ := strings.Cut(source[9:], "]]>")
+			p.addText(data)
+			source = rest
+		case markup.MatchString(source[:min(len(source), 2)]):
+			end := tagEnd(source)
+			if end < 0 {
+				// A tag cut off by a truncated description.
+				return
+			}
+			name, closing := p.tag(source[1:end])
+			source = source[end+1:]
+			if !closing && skippedElements[name] {
+				source = skipElement(source, name)
+			}
+		default:
+			p.addText("<")
+			source = source[1:]
+		}
+	}
+}
+
+// tag handles the tag whose source, without the angle brackets, is raw.
+func (p *parser) tag(raw string) (name string, closing bool) {
+	match := tagName.FindStringSubmatch(raw)
+	if match == nil {
+		// <!DOCTYPE>, <?xml?> and the like.
+		return "", false
+	}
+	name = strings.ToLower(match[1])
+	closing = raw[0] == '/'
+
+	if blockElements[name] {
+		p.flush()
+		return name, closing
+	}
+
+	switch name {
+	case "br":
+		p.lineBreak()
+	case "hr":
+		p.flush()
+		if !closing {
+			p.addBlock(block{text: "----"})
+		}
+	case "ul", "ol":
+		p.flush()
+		if closing {
+			if len(p.lists) > 0 {
+				p.lists = p.lists[:len(p.lists)-1]
+			}
+		} else {
+			p.lists = append(p.lists, list{ordered: name == "ol", next: 1})
+		}
+	case "li":
+		p.flush()
+		if !closing {
+			p.marker = "* "
+			if n := len(p.lists); n > 0 && p.lists[n-1].ordered {
+				p.marker = fmt.Sprintf("%d. ", p.lists[n-1].next)
+				p.lists[n-1].next++
+			}
+		}
+	case "blockquote":
+		p.flush()
+		if closing {
+			p.quote = max(p.quote-1, 0)
+		} else {
+			p.quote++
+		}
+	case "pre":
+		p.flush()
+		if closing {
+			p.pre = max(p.pre-1, 0)
+		} else {
+			p.pre++
+		}
+	case "td", "th":
+		if !closing {
+			p.addText(" ")
+		}
+	case "a":
+		if closing {
+			p.closeAnchor()
+		} else {
+			p.anchor = attributes(raw)["href"]
+			p.inAnchor = true
+			p.anchorText = p.text.Len()
+		}
+	case "img":
+		attrs := attributes(raw)
+		// One pixel images are trackers, not content.
+		if attrs["width"] == "1" || attrs["height"] == "1" {
+			break
+		}
+		label := "[image]"
+		if alt := strings.TrimSpace(attrs["alt"]); alt != "" {
+			label = "[image: " + alt + "]"
+		}
+		p.addText(" " + label + p.footnote(attrs["src"]) + " ")
+	case "iframe":
+		if !closing {
+			p.addText(" [embed]" + p.footnote(attributes(raw)["src"]) + " ")
+		}
+	case "video", "audio":
+		if !closing {
+			p.addText(" [" + name + "]" + p.footnote(attributes(raw)["src"]) + " ")
+		}
+	}
+	return name, closing
+}
+
+// closeAnchor footnotes the link just read, unless its text already shows
+// the URL.
+func (p *parser) closeAnchor() {
+	if !p.inAnchor {
+		return
+	}
+	p.inAnchor = false
+
+	label := ""
+	if p.anchorText <= p.text.Len() {
+		label = strings.TrimSpace(p.text.String()[p.anchorText:])
+	}
+	href := p.resolve(p.anchor)
+	if label == "" || href == "" || label == href || label == strings.TrimSpace(p.anchor) {
+		return
+	}
+	p.text.WriteString(p.footnote(p.anchor))
+}
+
+// footnote returns the [n] marker for the link, numbering it on first use.
+// Links that lead nowhere outside the post get no marker.
+func (p *parser) footnote(ref string) string {
+	link := p.resolve(ref)
+	if link == "" {
+		return ""
+	}
+	n, ok := p.doc.linkIndex[link]
+	if !ok {
+		p.doc.Links = append(p.doc.Links, link)
+		n = len(p.doc.Links)
+		p.doc.linkIndex[link] = n
+	}
+	return fmt.Sprintf("[%d]", n)
+}
+
+func (p *parser) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if p.base != nil {
+		u = p.base.ResolveReference(u)
+	}
+	switch u.Scheme {
+	case "http", "https", "mailto", "ftp":
+		return u.String()
+	default:
+		// Relative links without a base, and javascript: and data: URLs.
+		return ""
+	}
+}
+
+// addText appends text to the current block. Outside <pre> runs of white
+// space collapse to one space, as a browser would show them.
+func (p *parser) addText(text string) {
+	text = Sanitize(text)
+	if p.pre > 0 {
+		p.text.WriteString(strings.ReplaceAll(text, "\r\n", "\n"))
+		return
+	}
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			if current := p.text.String(); current != "" && !strings.HasSuffix(current, " ") && !strings.HasSuffix(current, "\n") {
+				p.text.WriteByte(' ')
+			}
+			continue
+		}
+		p.text.WriteRune(r)
+	}
+}
+
+func (p *parser) lineBreak() {
+	current := strings.TrimRight(p.text.String(), " ")
+	p.text.Reset()
+	p.text.WriteString(current)
+	p.text.WriteByte('\n')
+}
+
+// flush ends the current block, if it has any text.
+func (p *parser) flush() {
+	text := p.text.String()
+	p.text.Reset()
+	p.anchorText = 0
+	if p.pre == 0 {
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace(line)
+		}
+		text = strings.TrimSpace(strings.Join(lines, "\n"))
+	} else {
+		text = strings.Trim(text, "\n")
+	}
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	b := block{
+		text:   text,
+		quote:  p.quote,
+		pre:    p.pre > 0,
+		inList: len(p.lists) > 0 || p.marker != "",
+	}
+	if depth := len(p.lists); depth > 0 {
+		b.indent = 2 * (depth - 1)
+		if p.marker == "" {
+			b.indent += 2
+		}
+	}
+	b.marker = p.marker
+	p.marker = ""
+	p.addBlock(b)
+}
+
+func (p *parser) addBlock(b block) {
+	p.doc.blocks = append(p.doc.blocks, b)
+}
+
+// Text renders the document as plain text wrapped at width columns, followed
+// by the footnoted links. A width of zero or less leaves lines unwrapped.
+func (d *Document) Text(width int) string {
+	var out strings.Builder
+	for i, b := range d.blocks {
+		if i > 0 {
+			if b.inList && d.blocks[i-1].inList {
+				out.WriteString("\n")
+			} else {
+				out.WriteString("\n\n")
+			}
+		}
+		out.WriteString(strings.Join(b.lines(width), "\n"))
+	}
+
+	if len(d.Links) > 0 {
+		if out.Len() > 0 {
+			out.WriteString("\n\n")
+		}
+		for i, link := range d.Links {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			fmt.Fprintf(&out, "[%d] %s", i+1, link)
+		}
+	}
+	return out.String()
+}
+
+// lines lays the block out, wrapping it unless it is preformatted.
+func (b block) lines(width int) []string {
+	quote := strings.Repeat("> ", b.quote)
+	first := quote + strings.Repeat(" ", b.indent) + b.marker
+	rest := quote + strings.Repeat(" ", b.indent+utf8.RuneCountInString(b.marker))
+
+	var lines []string
+	for _, line := range strings.Split(b.text, "\n") {
+		if b.pre {
+			lines = append(lines, line)
+			continue
+		}
+		lines = append(lines, wrap(line, width-utf8.RuneCountInString(rest))...)
+	}
+	for i := range lines {
+		if i == 0 {
+			lines[i] = first + lines[i]
+		} else {
+			lines[i] = rest + lines[i]
+		}
+	}
+	return lines
+}
+
+// wrap breaks text into lines of at most width runes at spaces. Words longer
+// than a line, such as URLs, get a line of their own rather than being split.
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if width <= 0 || len(words) == 0 {
+		return []string{text}
+	}
+
+	var lines []string
+	line := words[0]
+	for _, word := range words[1:] {
+		if utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line = word
+		} else {
+			line += " " + word
+		}
+	}
+	return append(lines, line)
+}
+
+// Sanitize drops control characters, above all the escape sequences a feed
+// could use to take over the terminal. Tabs and newlines are kept.
+func Sanitize(text string) string {
+	text = escapeSequence.ReplaceAllString(text, "")
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, text)
+}
+
+// Plain makes a one line string from a feed, such as a post title, safe to
+// print: it is sanitized and its runs of white space, newlines included,
+// collapse to one space.
+func Plain(text string) string {
+	return strings.Join(strings.Fields(Sanitize(text)), " ")
+}
+
+// tagEnd returns the index of the '>' that closes the tag at the start of
+// source, skipping any inside quoted attribute values, or -1.
+func tagEnd(source string) int {
+	var quote byte
+	for i := 1; i < len(source); i++ {
+		switch c := source[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// skipElement returns what follows the end tag of the named element.
+func skipElement(source, name string) string {
+	for rest := source; ; {
+		i := strings.Index(rest, "</")
+		if i < 0 {
+			return ""
+		}
+		rest = rest[i+2:]
+		if len(rest) >= len(name) && strings.EqualFold(rest[:len(name)], name) {
+			return after(rest, ">")
+		}
+	}
+}
+
+// after returns what follows the first sep in s, or "" without one.
+func after(s, sep string) string {
+	_, rest, found := strings.Cut(s, sep)
+	if !found {
+		return ""
+	}
+	return rest
+}
+
+// attributes parses the attributes of a tag, lower-casing their names.
+func attributes(raw string) map[string]string {
+	attrs := map[string]string{}
+	for _, match := range tagAttribute.FindAllStringSubmatch(raw, -1) {
+		value := strings.Trim(match[2], `"'`)
+		attrs[strings.ToLower(match[1])] = html.UnescapeString(value)
+	}
+	return attrs
+}
@@ -0,0 +1,193 @@
+package render
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		width  int
+		want   string
+	}{
+		{
+			name:   "plain text paragraphs",
+			source: "First  line\nstill first.\n\nSecond &amp; last.",
+			want:   "First line still first.\n\nSecond & last.",
+		},
+		{
+			name:   "paragraphs and line breaks",
+			source: "<p>One <b>bold</b>\n word.</p><p>Two<br>lines</p>",
+			want:   "One bold word.\n\nTwo\nlines",
+		},
+		{
+			name:   "headings and divs are blocks",
+			source: "<h1>Title</h1><div>Body</div>text after",
+			want:   "Title\n\nBody\n\ntext after",
+		},
+		{
+			name:   "unordered list",
+			source: "<p>Intro</p><ul><li>one</li><li>two</li></ul><p>Outro</p>",
+			want:   "Intro\n\n* one\n* two\n\nOutro",
+		},
+		{
+			name:   "ordered list",
+			source: "<ol><li>first<li>second</ol>",
+			want:   "1. first\n2. second",
+		},
+		{
+			name:   "nested lists",
+			source: "<ul><li>a<ol><li>a1</li><li>a2</li></ol></li><li>b</li></ul>",
+			want:   "* a\n  1. a1\n  2. a2\n* b",
+		},
+		{
+			name:   "blockquote",
+			source: "<p>He said:</p><blockquote><p>quoted</p><blockquote>nested</blockquote></blockquote><p>done</p>",
+			want:   "He said:\n\n> quoted\n\n> > nested\n\ndone",
+		},
+		{
+			name:   "pre keeps white space",
+			source: "<p>Code:</p><pre>func main() {\r\n\tfmt.Println(\"&lt;hi&gt;\")\n}</pre>",
+			want:   "Code:\n\nfunc main() {\n\tfmt.Println(\"<hi>\")\n}",
+		},
+		{
+			name:   "anchors become footnotes",
+			source: `<p>Read <a href="/a">this</a> and <a href='https://b.example/'>that</a>, then <a href="/a">this again</a>.</p>`,
+			want:   "Read this[1] and that[2], then this again[1].\n\n[1] https://example.com/a\n[2] https://b.example/",
+		},
+		{
+			name:   "anchor showing its url has no footnote",
+			source: `<a href="https://example.com/x">https://example.com/x</a>`,
+			want:   "https://example.com/x",
+		},
+		{
+			name:   "fragment and script anchors have no footnote",
+			source: `<a href="#top">top</a> <a href="javascript:alert(1)">run</a>`,
+			want:   "top run",
+		},
+		{
+			name:   "images and trackers",
+			source: `<p><img src="pic.png" alt="A cat"><img src="/pixel.gif" width="1" height="1"><img src="b.png"></p>`,
+			want:   "[image: A cat][1] [image][2]\n\n[1] https://example.com/posts/pic.png\n[2] https://example.com/posts/b.png",
+		},
+		{
+			name:   "embeds",
+			source: `<iframe src="https://video.example/1"></iframe><video src="v.mp4"></video>`,
+			want:   "[embed][1] [video][2]\n\n[1] https://video.example/1\n[2] https://example.com/posts/v.mp4",
+		},
+		{
+			name:   "skipped elements and comments",
+			source: "<style>p { color: red }</style><p>shown<!-- hidden --></p><SCRIPT>alert('x')</script><p>also shown</p>",
+			want:   "shown\n\nalso shown",
+		},
+		{
+			name:   "entities and cdata",
+			source: "<p>&lt;tag&gt; &copy; <![CDATA[<raw>]]></p>",
+			want:   "<tag> © <raw>",
+		},
+		{
+			name:   "truncated tag",
+			source: `<p>cut off <a href="/x`,
+			want:   "cut off",
+		},
+		{
+			name:   "stray less than",
+			source: "<p>1 < 2</p>",
+			want:   "1 < 2",
+		},
+		{
+			name:   "ansi escape sequences stripped",
+			source: "<p>\x1b[31mred\x1b[0m and \x1b[2J\x07bell</p>",
+			want:   "red and bell",
+		},
+		{
+			name:   "window title escapes stripped",
+			source: "\x1b]0;title\x07plain \x1b]2;other\x1b\\\x1b[1mbold\x1b[0m",
+			want:   "plain bold",
+		},
+		{
+			name:   "wrapped",
+			source: "<p>the quick brown fox jumps over the lazy dog</p>",
+			width:  15,
+			want:   "the quick brown\nfox jumps over\nthe lazy dog",
+		},
+		{
+			name:   "wrapped list item keeps its indent",
+			source: "<ul><li>the quick brown fox jumps</li></ul>",
+			width:  12,
+			want:   "* the quick\n  brown fox\n  jumps",
+		},
+		{
+			name:   "wrapped quote",
+			source: "<blockquote>the quick brown fox</blockquote>",
+			width:  12,
+			want:   "> the quick\n> brown fox",
+		},
+		{
+			name:   "long words are not split",
+			source: "<p>see https://example.com/a/very/long/path now</p>",
+			width:  10,
+			want:   "see\nhttps://example.com/a/very/long/path\nnow",
+		},
+		{
+			name:   "pre is not wrapped",
+			source: "<pre>the quick brown fox</pre>",
+			width:  5,
+			want:   "the quick brown fox",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HTML(tt.source, "https://example.com/posts/1").Text(tt.width)
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLWithoutBase(t *testing.T) {
+	doc := HTML(`<a href="/relative">here</a> and <a href="https://example.com/">there</a>`, "")
+	if got, want := doc.Text(0), "here and there[1]\n\n[1] https://example.com/"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "plain"},
+		{"keeps\ttabs\nand newlines", "keeps\ttabs\nand newlines"},
+		{"\x1b[31mred\x1b[0m", "red"},
+		{"\x1b[2J\x1b[?25lclear", "clear"},
+		{"bell\x07 and nul\x00 and del\x7f", "bell and nul and del"},
+		{"c1 \u009b31m control", "c1 31m control"},
+	}
+
+	for _, tt := range tests {
+		if got := Sanitize(tt.text); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPlain(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"A title", "A title"},
+		{"  A\n\ttitle  ", "A title"},
+		{"\x1b[1mBold\x1b[0m title\r\n", "Bold title"},
+		{"\x1b]0;pwned\x07Title", "Title"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Plain(tt.text); got != tt.want {
+			t.Errorf("Plain(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
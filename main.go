@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/lib/pq"
 	"github.com/thedevscott/blogaggregator/internal/commands"
@@ -24,9 +27,16 @@ func main() {
 	defer db.Close()
 	dbQueries := database.New(db)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Only the first signal is caught so commands can shut down cleanly; after
+	// it the default handling is back and a second Ctrl-C quits at once.
+	context.AfterFunc(ctx, stop)
+
 	programState := &commands.State{
 		Db:  dbQueries,
 		Cfg: &cfg,
+		Ctx: ctx,
 	}
 
 	cmds := commands.Commands{
@@ -38,10 +48,16 @@ func main() {
 	cmds.Register("reset", commands.HandlerResetUsers)
 	cmds.Register("users", commands.HandlerGetUsers)
 	cmds.Register("agg", commands.HandlerAggregate)
+	cmds.Register("fetch", commands.HandlerFetch)
 	cmds.Register("addfeed", commands.MiddlewareLoggedIn(commands.HandlerAddFeed))
 	cmds.Register("feeds", commands.HandlerGetFeed)
+	cmds.Register("setinterval", commands.HandlerSetInterval)
+	cmds.Register("enablefeed", commands.HandlerEnableFeed)
+	cmds.Register("fetchlog", commands.HandlerFetchLog)
 	cmds.Register("follow", commands.MiddlewareLoggedIn(commands.HandlerFollow))
 	cmds.Register("browse", commands.MiddlewareLoggedIn(commands.HandlerBrowse))
+	cmds.Register("read", commands.HandlerRead)
+	cmds.Register("categories", commands.MiddlewareLoggedIn(commands.HandlerCategories))
 	cmds.Register("unfollow", commands.MiddlewareLoggedIn(commands.HandlerUnfollow))
 	cmds.Register("following", commands.MiddlewareLoggedIn(commands.HandlerFollowing))
 
@@ -1,6 +1,6 @@
 -- name: CreateFeed :one
-INSERT INTO feeds(id, created_at, updated_at, name, url, user_id)
-VALUES($1, $2, $3, $4, $5, $6)
+INSERT INTO feeds(id, created_at, updated_at, name, url, user_id, site_url, description, language, image_url)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING *;
 
 -- name: GetFeeds :many
@@ -12,15 +12,151 @@ SELECT *
 FROM feeds
 WHERE url = $1;
 
--- name: MarkFeedFetched :one
+-- name: UpdateFeedCacheValidators :exec
 UPDATE feeds
-SET last_fetched_at = NOW(),
+SET etag = $2,
+last_modified = $3,
 updated_at = NOW()
+WHERE id = $1;
+
+-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET claimed_until = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second')
+WHERE id IN (
+    SELECT id
+    FROM feeds
+    WHERE disabled_at IS NULL
+    AND (claimed_until IS NULL OR claimed_until < NOW())
+    AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    AND NOT EXISTS (
+        SELECT 1
+        FROM host_backoffs
+        WHERE host_backoffs.host = lower(substring(feeds.url from '://([^/:?#]+)'))
+        AND host_backoffs.retry_after > NOW()
+    )
+    ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ReleaseFeedClaim :exec
+UPDATE feeds
+SET claimed_until = NULL
 WHERE id = $1
+AND claimed_until = $2;
+
+-- name: RenewFeedClaim :one
+UPDATE feeds
+SET claimed_until = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second')
+WHERE id = sqlc.arg(id)
+AND claimed_until = sqlc.arg(claimed_until)
 RETURNING *;
 
--- name: GetNextFeedToFetch :one
-SELECT * 
+-- name: UpdateFeedPollingHints :exec
+UPDATE feeds
+SET publisher_interval_seconds = $2,
+skip_hours = $3,
+skip_days = $4
+WHERE id = $1;
+
+-- name: SetFeedNextFetchAt :exec
+UPDATE feeds
+SET next_fetch_at = $2
+WHERE id = $1;
+
+-- name: SetFeedFetchInterval :one
+UPDATE feeds
+SET fetch_interval_seconds = $2,
+next_fetch_at = NULL,
+updated_at = NOW()
+WHERE url = $1
+RETURNING *;
+
+-- name: RecordFeedFailure :one
+UPDATE feeds
+SET last_error = sqlc.arg(last_error),
+consecutive_failures = consecutive_failures + 1,
+disabled_at = CASE
+    WHEN consecutive_failures + 1 >= sqlc.arg(disable_after)::int THEN NOW()
+    ELSE disabled_at
+END,
+updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET last_fetched_at = NOW(),
+last_error = NULL,
+consecutive_failures = 0,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetFeedsWithErrors :many
+SELECT *
+FROM feeds
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at ASC NULLS LAST, consecutive_failures DESC;
+
+-- name: EnableFeed :one
+UPDATE feeds
+SET disabled_at = NULL,
+consecutive_failures = 0,
+last_error = NULL,
+next_fetch_at = NULL,
+updated_at = NOW()
+WHERE url = $1
+RETURNING *;
+
+-- name: ClaimFeed :one
+UPDATE feeds
+SET claimed_until = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second')
+WHERE id = sqlc.arg(id)
+AND (claimed_until IS NULL OR claimed_until < NOW())
+RETURNING *;
+
+-- name: GetFeedsByName :many
+SELECT *
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
\ No newline at end of file
+WHERE name = $1;
+
+-- name: MarkFeedGone :one
+UPDATE feeds
+SET last_error = sqlc.arg(last_error),
+consecutive_failures = consecutive_failures + 1,
+disabled_at = NOW(),
+updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: MergeFeedInto :exec
+WITH moved_follows AS (
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+    SELECT gen_random_uuid(), NOW(), NOW(), user_id, sqlc.arg(target_id)::uuid
+    FROM feed_follows
+    WHERE feed_id = sqlc.arg(source_id)
+    ON CONFLICT (user_id, feed_id) DO NOTHING
+), moved_posts AS (
+    UPDATE posts
+    SET feed_id = sqlc.arg(target_id)::uuid
+    WHERE feed_id = sqlc.arg(source_id)
+    AND guid NOT IN (SELECT guid FROM posts WHERE feed_id = sqlc.arg(target_id)::uuid)
+)
+DELETE FROM feeds
+WHERE id = sqlc.arg(source_id);
+
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET site_url = $2,
+description = $3,
+language = $4,
+image_url = $5,
+updated_at = NOW()
+WHERE id = $1;
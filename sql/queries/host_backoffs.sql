@@ -0,0 +1,11 @@
+-- name: SetHostBackoff :exec
+INSERT INTO host_backoffs (host, retry_after, updated_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (host) DO UPDATE
+SET retry_after = GREATEST(host_backoffs.retry_after, EXCLUDED.retry_after),
+updated_at = NOW();
+
+-- name: GetHostBackoff :one
+SELECT *
+FROM host_backoffs
+WHERE host = $1 AND retry_after > NOW();
@@ -0,0 +1,25 @@
+-- name: AddPostCategory :exec
+INSERT INTO post_categories (post_id, name)
+VALUES ($1, $2)
+ON CONFLICT (post_id, name) DO NOTHING;
+
+-- name: DeleteOtherPostCategories :exec
+DELETE FROM post_categories
+WHERE post_id = sqlc.arg(post_id)
+AND name <> ALL(sqlc.arg(keep)::text[]);
+
+-- name: GetCategoriesForPosts :many
+SELECT *
+FROM post_categories
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY post_id, name;
+
+-- name: GetTopCategoriesForUser :many
+SELECT lower(post_categories.name) AS name, COUNT(*) AS post_count
+FROM post_categories
+JOIN posts ON posts.id = post_categories.post_id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+GROUP BY lower(post_categories.name)
+ORDER BY post_count DESC, name
+LIMIT $2;
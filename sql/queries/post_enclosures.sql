@@ -0,0 +1,16 @@
+-- name: UpsertPostEnclosure :exec
+INSERT INTO post_enclosures (id, created_at, post_id, url, mime_type, length, duration_seconds, episode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (post_id, url) DO UPDATE
+SET mime_type = EXCLUDED.mime_type,
+    length = EXCLUDED.length,
+    duration_seconds = EXCLUDED.duration_seconds,
+    episode = EXCLUDED.episode
+WHERE (post_enclosures.mime_type, post_enclosures.length, post_enclosures.duration_seconds, post_enclosures.episode)
+    IS DISTINCT FROM (EXCLUDED.mime_type, EXCLUDED.length, EXCLUDED.duration_seconds, EXCLUDED.episode);
+
+-- name: GetEnclosuresForPosts :many
+SELECT *
+FROM post_enclosures
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY post_id, created_at;
@@ -1,5 +1,5 @@
--- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, published_at_inferred, guid, content_hash, content, author, original_url)
 VALUES (
     $1,
     $2,
@@ -8,14 +8,59 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14
 )
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    author = EXCLUDED.author,
+    url = EXCLUDED.url,
+    original_url = EXCLUDED.original_url,
+    content_hash = EXCLUDED.content_hash,
+    updated_at = EXCLUDED.updated_at,
+    revised_at = CASE WHEN posts.content_hash = '' OR (posts.content = '' AND EXCLUDED.content <> '') THEN posts.revised_at ELSE EXCLUDED.updated_at END
+WHERE posts.content_hash <> EXCLUDED.content_hash
 RETURNING *;
 
+-- name: RekeyPost :exec
+UPDATE posts
+SET guid = sqlc.arg(new_guid)
+WHERE feed_id = sqlc.arg(feed_id)
+AND guid = sqlc.arg(old_guid)
+AND NOT EXISTS (
+    SELECT 1 FROM posts AS rekeyed
+    WHERE rekeyed.feed_id = sqlc.arg(feed_id)
+    AND rekeyed.guid = sqlc.arg(new_guid)
+);
+
+-- name: UpdatePostAuthor :exec
+UPDATE posts
+SET author = $2
+WHERE id = $1 AND author <> $2;
+
+-- name: CountRecentPostsForFeed :one
+SELECT COUNT(*)
+FROM posts
+WHERE feed_id = $1
+AND published_at_inferred = false
+AND published_at > $2;
+
 -- name: GetPosts :many
 SELECT * 
 FROM posts ORDER BY published_at ASC LIMIT $1;
 
+-- name: GetPostIDByGuid :one
+SELECT id
+FROM posts
+WHERE feed_id = $1 AND guid = $2;
+
 -- name: GetPostById :one
 SELECT * 
 FROM posts
@@ -25,9 +70,22 @@ WHERE id = $1;
 SELECT posts.*, feeds.name AS feed_name FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON posts.feed_id = feeds.id
-WHERE feed_follows.user_id = $1
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND (NOT sqlc.arg(podcasts_only)::bool OR EXISTS (
+    SELECT 1
+    FROM post_enclosures
+    WHERE post_enclosures.post_id = posts.id
+    AND (post_enclosures.mime_type LIKE 'audio/%' OR post_enclosures.mime_type LIKE 'video/%')
+))
+AND (sqlc.arg(author)::text = '' OR posts.author ILIKE '%' || sqlc.arg(author)::text || '%')
+AND (sqlc.arg(category)::text = '' OR EXISTS (
+    SELECT 1
+    FROM post_categories
+    WHERE post_categories.post_id = posts.id
+    AND lower(post_categories.name) = lower(sqlc.arg(category)::text)
+))
 ORDER BY posts.published_at DESC
-LIMIT $2;
+LIMIT sqlc.arg('limit');
 
 -- name: ResetPosts :exec
 DELETE FROM posts *;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN revised_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN revised_at;
+ALTER TABLE posts DROP COLUMN content_hash;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN next_fetch_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN fetch_interval_seconds INT;
+ALTER TABLE feeds ADD COLUMN publisher_interval_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN skip_hours INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN skip_days INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN skip_days;
+ALTER TABLE feeds DROP COLUMN skip_hours;
+ALTER TABLE feeds DROP COLUMN publisher_interval_seconds;
+ALTER TABLE feeds DROP COLUMN fetch_interval_seconds;
+ALTER TABLE feeds DROP COLUMN next_fetch_at;
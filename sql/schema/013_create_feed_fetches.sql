@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE feed_fetches (
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    http_status INT,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    items_seen INT NOT NULL DEFAULT 0,
+    items_inserted INT NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX feed_fetches_feed_id_started_at_idx ON feed_fetches (feed_id, started_at DESC);
+
+-- +goose Down
+DROP TABLE feed_fetches;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE host_backoffs (
+    host TEXT PRIMARY KEY,
+    retry_after TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE host_backoffs;
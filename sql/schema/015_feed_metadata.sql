@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN site_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN language TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN image_url TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN image_url;
+ALTER TABLE feeds DROP COLUMN language;
+ALTER TABLE feeds DROP COLUMN description;
+ALTER TABLE feeds DROP COLUMN site_url;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE post_enclosures (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    mime_type TEXT NOT NULL DEFAULT '',
+    length BIGINT NOT NULL DEFAULT 0,
+    duration_seconds INT NOT NULL DEFAULT 0,
+    episode INT,
+    UNIQUE (post_id, url)
+);
+
+-- +goose Down
+DROP TABLE post_enclosures;
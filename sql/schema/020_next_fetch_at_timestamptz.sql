@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ALTER COLUMN next_fetch_at TYPE TIMESTAMPTZ USING next_fetch_at AT TIME ZONE 'UTC';
+
+-- +goose Down
+ALTER TABLE feeds ALTER COLUMN next_fetch_at TYPE TIMESTAMP USING next_fetch_at AT TIME ZONE 'UTC';
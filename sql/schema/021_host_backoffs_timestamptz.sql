@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE host_backoffs ALTER COLUMN retry_after TYPE TIMESTAMPTZ USING retry_after AT TIME ZONE 'UTC';
+
+-- +goose Down
+ALTER TABLE host_backoffs ALTER COLUMN retry_after TYPE TIMESTAMP USING retry_after AT TIME ZONE 'UTC';